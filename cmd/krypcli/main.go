@@ -6,6 +6,7 @@ package main
 import (
         "bytes"
         "crypto/ecdsa"
+        "encoding/csv"
         "encoding/hex"
         "encoding/json"
         "flag"
@@ -29,6 +30,13 @@ func main() {
         case "new":     newWallet()
         case "balance": balance()
         case "send":    send()
+        case "send-batch": sendBatch()
+        case "speedup": replace(false)
+        case "multisig-new":    multisigNew()
+        case "multisig-tx":     multisigTx()
+        case "multisig-sign":   multisigSign()
+        case "multisig-submit": multisigSubmit()
+        case "cancel":  replace(true)
         default: usage()
         }
 }
@@ -37,7 +45,16 @@ func usage() {
         fmt.Println("krypcli usage:")
         fmt.Println("  krypcli new")
         fmt.Println("  krypcli balance -addr 0x...")
-        fmt.Println("  krypcli send -priv HEX -to ADDRESS -amount WEI")
+        fmt.Println("  krypcli send -priv HEX -to ADDRESS -amount WEI [-tip WEI] [-maxfee WEI]")
+        fmt.Println("  krypcli send-batch -priv HEX -file PAYOUTS.csv [-tip WEI] [-maxfee WEI]")
+        fmt.Println("  krypcli speedup -priv HEX -nonce N [-bump PCT]")
+        fmt.Println("  krypcli cancel -priv HEX -nonce N [-bump PCT]")
+        fmt.Println("  krypcli speedup|cancel -from MULTISIG -nonce N [-bump PCT] -out FILE   (then multisig-sign)")
+        fmt.Println("  krypcli multisig-new -priv HEX -signers ADDR,ADDR,... -threshold M [-amount WEI]")
+        fmt.Println("  krypcli multisig-tx -from MULTISIG -to ADDRESS -amount WEI -out FILE")
+        fmt.Println("  krypcli multisig-tx -from MULTISIG -signers ADDR,... -threshold M -out FILE   (rotate signers)")
+        fmt.Println("  krypcli multisig-sign -priv HEX -file FILE")
+        fmt.Println("  krypcli multisig-submit -file FILE")
 }
 
 // ---------------- KEY GEN ----------------
@@ -71,6 +88,9 @@ func send() {
         priv := fs.String("priv","", "private hex")
         to   := fs.String("to","",   "receiver")
         amt  := fs.String("amount","", "wei")
+        chain  := fs.Uint64("chain",1,"chain id")
+        tipStr := fs.String("tip","", "priority fee per gas in wei (default: node suggestion)")
+        capStr := fs.String("maxfee","", "max fee per gas in wei (default: node suggestion)")
 
         fs.Parse(os.Args[2:])
 
@@ -79,27 +99,294 @@ func send() {
         value,_    := new(big.Int).SetString(*amt,10)
         nonce      := getNonce(*rpcURL,from)
 
-        tx := types.NewTransferTx(1,nonce,toAddr,value,big.NewInt(1_000_000_000),21000,nil)
-        types.SignTransaction(tx,key)
-
-        req := map[string]any{
-                "chainId":"1",
-                "nonce": tx.Nonce,
-                "to":    tx.To.String(),
-                "value": tx.Value.String(),
-                "gasPrice": tx.GasPrice.String(),
-                "gasLimit": tx.GasLimit,
-                "data": "0x"+hex.EncodeToString(tx.Data),
-                "r": "0x"+tx.Signature.R.Text(16),
-                "s": "0x"+tx.Signature.S.Text(16),
-                "v": tx.Signature.V,
+        maxFee,tip := suggestFees(*rpcURL)
+        if *tipStr != "" { tip,_ = new(big.Int).SetString(*tipStr,10) }
+        if *capStr != "" { maxFee,_ = new(big.Int).SetString(*capStr,10) }
+        if maxFee == nil || tip == nil { fmt.Println("invalid fee"); return }
+
+        tx := types.NewTransferTx(*chain,nonce,toAddr,value,nil,types.TxGas,nil)
+        tx.SetDynamicFee(maxFee,tip)
+        if err := types.SignTransaction(tx,key); err != nil { fmt.Println("sign:",err); return }
+
+        fmt.Println("Fees → maxFeePerGas:",maxFee,"maxPriorityFeePerGas:",tip)
+        submitTx(*rpcURL,tx)
+}
+
+// ---------------- SEND BATCH ----------------
+
+// sendBatch pays every "address,amount" row of a CSV file (amount in wei)
+// in one batch transaction. A header row is skipped.
+func sendBatch() {
+        fs := flag.NewFlagSet("send-batch",flag.ExitOnError)
+        rpcURL := fs.String("rpc",RPC,"node rpc")
+        priv   := fs.String("priv","", "private hex")
+        file   := fs.String("file","", "CSV of address,amount rows")
+        chain  := fs.Uint64("chain",1,"chain id")
+        tipStr := fs.String("tip","", "priority fee per gas in wei (default: node suggestion)")
+        capStr := fs.String("maxfee","", "max fee per gas in wei (default: node suggestion)")
+
+        fs.Parse(os.Args[2:])
+
+        transfers,err := readBatchCSV(*file)
+        if err != nil { fmt.Println("csv:",err); return }
+
+        key,from,_ := loadKey(*priv)
+        nonce      := getNonce(*rpcURL,from)
+
+        maxFee,tip := suggestFees(*rpcURL)
+        if *tipStr != "" { tip,_ = new(big.Int).SetString(*tipStr,10) }
+        if *capStr != "" { maxFee,_ = new(big.Int).SetString(*capStr,10) }
+        if maxFee == nil || tip == nil { fmt.Println("invalid fee"); return }
+
+        tx,err := types.NewBatchTransferTx(*chain,nonce,transfers,nil,0)
+        if err != nil { fmt.Println("batch:",err); return }
+        tx.GasLimit = types.IntrinsicGas(tx)
+        tx.SetDynamicFee(maxFee,tip)
+        if err := types.SignTransaction(tx,key); err != nil { fmt.Println("sign:",err); return }
+
+        fmt.Println("Batch →",len(transfers),"transfers, total:",tx.Value,"gas:",tx.GasLimit)
+        submitTx(*rpcURL,tx)
+}
+
+func readBatchCSV(path string)([]types.BatchTransfer,error){
+        f,err := os.Open(path)
+        if err != nil { return nil,err }
+        defer f.Close()
+
+        r := csv.NewReader(f)
+        r.FieldsPerRecord = 2
+        r.TrimLeadingSpace = true
+        rows,err := r.ReadAll()
+        if err != nil { return nil,err }
+
+        var out []types.BatchTransfer
+        for i,row := range rows {
+                addr,err := types.ParseAddress(row[0])
+                amt,ok   := new(big.Int).SetString(strings.TrimSpace(row[1]),10)
+                if err != nil || !ok {
+                        if i == 0 { continue } // header
+                        return nil,fmt.Errorf("line %d: invalid row",i+1)
+                }
+                out = append(out,types.BatchTransfer{To:addr,Amount:amt})
         }
+        if len(out) == 0 { return nil,fmt.Errorf("no transfers") }
+        return out,nil
+}
 
-        b,_ := json.Marshal(req)
-        resp,_ := http.Post(*rpcURL+"/tx/send","application/json",bytes.NewReader(b))
-        out,_  := io.ReadAll(resp.Body)
+// ---------------- SPEED UP / CANCEL ----------------
 
-        fmt.Println("TX →",string(out))
+// replace re-sends the pending tx at -nonce with fees raised by -bump %
+// (or to the node suggestion, if higher). cancel turns it into a zero
+// value transfer to the sender itself.
+func replace(cancel bool) {
+        name := "speedup"
+        if cancel { name = "cancel" }
+        fs := flag.NewFlagSet(name,flag.ExitOnError)
+        rpcURL  := fs.String("rpc",RPC,"node rpc")
+        priv    := fs.String("priv","", "private hex")
+        fromStr := fs.String("from","", "multisig account (replacement goes through multisig-sign)")
+        out     := fs.String("out","multisig-tx.json","partially-signed tx file (multisig only)")
+        nonce   := fs.Uint64("nonce",0,"nonce of the pending tx")
+        bump    := fs.Uint64("bump",types.DefaultPriceBump,"fee increase in percent")
+
+        fs.Parse(os.Args[2:])
+
+        var key *ecdsa.PrivateKey
+        var from types.Address
+        if *fromStr != "" {
+                from,_ = parseAddr(*fromStr)
+        } else {
+                key,from,_ = loadKey(*priv)
+        }
+        old := getPendingTx(*rpcURL,from,*nonce)
+        if old == nil { fmt.Println("no pending tx with nonce",*nonce); return }
+        if old.IsMultisig() != (*fromStr != "") {
+                if old.IsMultisig() {
+                        fmt.Println("pending tx is from a multisig account: use -from",old.Multisig.String())
+                } else {
+                        fmt.Println("pending tx is not from a multisig account: use -priv")
+                }
+                return
+        }
+
+        maxFee := types.BumpFee(old.FeeCap(),*bump)
+        tip    := types.BumpFee(old.TipCap(),*bump)
+        if sFee,sTip := suggestFees(*rpcURL); sFee != nil && sTip != nil {
+                if sFee.Cmp(maxFee) > 0 { maxFee = sFee }
+                if sTip.Cmp(tip) > 0 { tip = sTip }
+        }
+        if tip.Cmp(maxFee) > 0 { maxFee = new(big.Int).Set(tip) }
+
+        tx := old
+        if cancel { tx = types.NewTransferTx(old.ChainId.Uint64(),old.Nonce,from,big.NewInt(0),nil,types.TxGas,nil) }
+        tx.SetDynamicFee(maxFee,tip)
+        fmt.Println("Replacing nonce",*nonce,"→ maxFeePerGas:",maxFee,"maxPriorityFeePerGas:",tip)
+
+        // A multisig replacement needs fresh cosignatures: the old ones
+        // cover the old fees. Write it out for multisig-sign instead.
+        if old.IsMultisig() {
+                threshold,_,err := getMultisig(*rpcURL,from)
+                if err != nil { fmt.Println("multisig:",err); return }
+                tx.SetMultisig(from)
+                if cancel { tx.GasLimit = types.IntrinsicGas(tx)+threshold*types.TxCosignatureGas }
+                if err := writeTxFile(*out,tx); err != nil { fmt.Println("write:",err); return }
+                fmt.Println("Wrote",*out,"- needs",threshold,"signatures (multisig-sign, then multisig-submit)")
+                return
+        }
+
+        if err := types.SignTransaction(tx,key); err != nil { fmt.Println("sign:",err); return }
+        submitTx(*rpcURL,tx)
+}
+
+// ---------------- MULTISIG ----------------
+
+// multisigNew creates a multisig account owned by -signers, funded with
+// -amount from the -priv account, and prints its address.
+func multisigNew() {
+        fs := flag.NewFlagSet("multisig-new",flag.ExitOnError)
+        rpcURL  := fs.String("rpc",RPC,"node rpc")
+        priv    := fs.String("priv","", "private hex of the creating account")
+        signers := fs.String("signers","", "comma separated signer addresses")
+        thresh  := fs.Uint64("threshold",0,"signatures required")
+        amt     := fs.String("amount","0", "initial funding in wei")
+        chain   := fs.Uint64("chain",1,"chain id")
+
+        fs.Parse(os.Args[2:])
+
+        policy,err := parsePolicy(*signers,*thresh)
+        if err != nil { fmt.Println("policy:",err); return }
+
+        key,from,_ := loadKey(*priv)
+        value,ok   := new(big.Int).SetString(*amt,10)
+        if !ok { fmt.Println("invalid amount"); return }
+        nonce      := getNonce(*rpcURL,from)
+
+        maxFee,tip := suggestFees(*rpcURL)
+        if maxFee == nil || tip == nil { fmt.Println("invalid fee"); return }
+
+        tx,err := types.NewMultisigCreateTx(*chain,nonce,policy,value,nil,0)
+        if err != nil { fmt.Println("multisig:",err); return }
+        tx.GasLimit = types.IntrinsicGas(tx)
+        tx.SetDynamicFee(maxFee,tip)
+        if err := types.SignTransaction(tx,key); err != nil { fmt.Println("sign:",err); return }
+
+        fmt.Println("Multisig address:",types.MultisigAddress(from,nonce).String())
+        submitTx(*rpcURL,tx)
+}
+
+// multisigTx writes an unsigned tx from a multisig account to -out: a
+// transfer, or a signer rotation if -signers is given.
+func multisigTx() {
+        fs := flag.NewFlagSet("multisig-tx",flag.ExitOnError)
+        rpcURL  := fs.String("rpc",RPC,"node rpc")
+        fromStr := fs.String("from","", "multisig account")
+        to      := fs.String("to","", "receiver")
+        amt     := fs.String("amount","0", "wei")
+        signers := fs.String("signers","", "new signer set (rotation)")
+        thresh  := fs.Uint64("threshold",0,"new threshold (rotation)")
+        out     := fs.String("out","multisig-tx.json","partially-signed tx file")
+        chain   := fs.Uint64("chain",1,"chain id")
+        tipStr  := fs.String("tip","", "priority fee per gas in wei (default: node suggestion)")
+        capStr  := fs.String("maxfee","", "max fee per gas in wei (default: node suggestion)")
+
+        fs.Parse(os.Args[2:])
+
+        from,_ := parseAddr(*fromStr)
+        threshold,nonce,err := getMultisig(*rpcURL,from)
+        if err != nil { fmt.Println("multisig:",err); return }
+
+        maxFee,tip := suggestFees(*rpcURL)
+        if *tipStr != "" { tip,_ = new(big.Int).SetString(*tipStr,10) }
+        if *capStr != "" { maxFee,_ = new(big.Int).SetString(*capStr,10) }
+        if maxFee == nil || tip == nil { fmt.Println("invalid fee"); return }
+
+        var tx *types.Transaction
+        if *signers != "" {
+                policy,err := parsePolicy(*signers,*thresh)
+                if err != nil { fmt.Println("policy:",err); return }
+                tx,_ = types.NewMultisigUpdateTx(*chain,nonce,from,policy,nil,0)
+        } else {
+                toAddr,_ := parseAddr(*to)
+                value,ok := new(big.Int).SetString(*amt,10)
+                if !ok { fmt.Println("invalid amount"); return }
+                tx = types.NewTransferTx(*chain,nonce,toAddr,value,nil,0,nil)
+                tx.SetMultisig(from)
+        }
+        // gas covers the cosignatures the current policy requires
+        tx.GasLimit = types.IntrinsicGas(tx)+threshold*types.TxCosignatureGas
+        tx.SetDynamicFee(maxFee,tip)
+
+        if err := writeTxFile(*out,tx); err != nil { fmt.Println("write:",err); return }
+        fmt.Println("Wrote",*out,"- needs",threshold,"signatures")
+}
+
+// multisigSign adds the -priv signer's cosignature to a tx file.
+func multisigSign() {
+        fs := flag.NewFlagSet("multisig-sign",flag.ExitOnError)
+        priv := fs.String("priv","", "private hex of a signer")
+        file := fs.String("file","multisig-tx.json","partially-signed tx file")
+
+        fs.Parse(os.Args[2:])
+
+        tx,err := readTxFile(*file)
+        if err != nil { fmt.Println("read:",err); return }
+        key,addr,_ := loadKey(*priv)
+        if err := types.CosignTransaction(tx,key); err != nil { fmt.Println("sign:",err); return }
+        if err := writeTxFile(*file,tx); err != nil { fmt.Println("write:",err); return }
+
+        fmt.Println("Signed by",addr.String(),"-",len(tx.Cosignatures),"signatures")
+}
+
+// multisigSubmit sends a cosigned tx file to the node.
+func multisigSubmit() {
+        fs := flag.NewFlagSet("multisig-submit",flag.ExitOnError)
+        rpcURL := fs.String("rpc",RPC,"node rpc")
+        file   := fs.String("file","multisig-tx.json","partially-signed tx file")
+
+        fs.Parse(os.Args[2:])
+
+        tx,err := readTxFile(*file)
+        if err != nil { fmt.Println("read:",err); return }
+        submitTx(*rpcURL,tx)
+}
+
+func parsePolicy(list string,threshold uint64)(*types.MultisigPolicy,error){
+        var signers []types.Address
+        for _,s := range strings.Split(list,",") {
+                if s = strings.TrimSpace(s); s == "" { continue }
+                a,err := types.ParseAddress(s)
+                if err != nil { return nil,err }
+                signers = append(signers,a)
+        }
+        return types.NewMultisigPolicy(signers,threshold)
+}
+
+// getMultisig returns the threshold and nonce of a multisig account.
+func getMultisig(url string,addr types.Address)(threshold,nonce uint64,err error){
+        r,err := http.Get(url+"/account/multisig?address="+addr.String())
+        if err != nil { return 0,0,err }
+        b,_ := io.ReadAll(r.Body)
+        if r.StatusCode != http.StatusOK { return 0,0,fmt.Errorf("%s",strings.TrimSpace(string(b))) }
+        var out struct{
+                Threshold uint64 `json:"threshold"`
+                Nonce     uint64 `json:"nonce"`
+        }
+        err = json.Unmarshal(b,&out)
+        return out.Threshold,out.Nonce,err
+}
+
+func writeTxFile(path string,tx *types.Transaction)error{
+        b,err := json.MarshalIndent(tx,"","  ")
+        if err != nil { return err }
+        return os.WriteFile(path,b,0o644)
+}
+
+func readTxFile(path string)(*types.Transaction,error){
+        b,err := os.ReadFile(path)
+        if err != nil { return nil,err }
+        var tx types.Transaction
+        if err := json.Unmarshal(b,&tx); err != nil { return nil,err }
+        return &tx,nil
 }
 
 // ---------------- HELPERS ----------------
@@ -121,6 +408,37 @@ func parseAddr(s string)(types.Address,error){
         return a,nil
 }
 
+func submitTx(url string,tx *types.Transaction){
+        b,_ := json.Marshal(tx)
+        resp,err := http.Post(url+"/tx/send","application/json",bytes.NewReader(b))
+        if err != nil { fmt.Println("rpc:",err); return }
+        out,_ := io.ReadAll(resp.Body)
+
+        fmt.Println("TX →",string(out))
+}
+
+// suggestFees asks the node for fee caps; nil results mean unavailable.
+func suggestFees(url string)(maxFee,tip *big.Int){
+        b:=httpGet(url+"/fees/suggest")
+        var out struct{
+                MaxFee string `json:"maxFeePerGas"`
+                Tip    string `json:"maxPriorityFeePerGas"`
+        }
+        json.Unmarshal(b,&out)
+        maxFee,_ = new(big.Int).SetString(out.MaxFee,10)
+        tip,_    = new(big.Int).SetString(out.Tip,10)
+        return maxFee,tip
+}
+
+// getPendingTx fetches the pooled tx of addr with nonce; nil if none.
+func getPendingTx(url string,addr types.Address,nonce uint64)*types.Transaction{
+        r,err := http.Get(fmt.Sprintf("%s/tx/pending?address=%s&nonce=%d",url,addr.String(),nonce))
+        if err != nil || r.StatusCode != http.StatusOK { return nil }
+        var tx types.Transaction
+        if json.NewDecoder(r.Body).Decode(&tx) != nil { return nil }
+        return &tx
+}
+
 func getNonce(url string,addr types.Address)uint64{
         b:=httpGet(url+"/account/balance?address="+addr.String())
         var out struct{Nonce uint64 `json:"nonce"` }
@@ -4,6 +4,7 @@
 package main
 
 import (
+        "bytes"
         "crypto/ecdsa"
         "encoding/hex"
         "encoding/json"
@@ -22,11 +23,24 @@ import (
 
 const defaultRPC = "http://localhost:8000"
 
+// maxBackoff caps the retry delay after consecutive RPC errors.
+const maxBackoff = 60 * time.Second
+
 type headResponse struct {
-        Header struct {
-                Height    uint64 `json:"height"`
-                Timestamp int64  `json:"timestamp"`
-        } `json:"header"`
+        Height uint64 `json:"height"`
+        Hash   string `json:"hash"`
+}
+
+type balanceResponse struct {
+        Balance string `json:"balance"`
+}
+
+// stats tracks witness submissions and rewards for this session.
+type stats struct {
+        accepted     uint64
+        rejected     uint64
+        startBalance *big.Int
+        lastBalance  *big.Int
 }
 
 func main() {
@@ -46,58 +60,131 @@ func main() {
                 log.Fatalf("invalid private key: %v", err)
         }
 
+        base := strings.TrimRight(*rpcURL, "/")
+        pollEvery := time.Duration(*interval) * time.Second
+
         fmt.Println("=== KRYPPER Tier3 Mobile Miner ===")
-        fmt.Println("RPC:", *rpcURL)
+        fmt.Println("RPC:", base)
         fmt.Println("Address:", addr.String())
         fmt.Println("Interval:", *interval, "sec")
 
-        var lastHeight uint64 = 0
+        var (
+                st         stats
+                lastHeight uint64
+                witnessed  bool
+                delay      = pollEvery
+        )
 
         for {
-                h, err := fetchHead(*rpcURL)
+                if err := step(base, privKey, addr, &st, &lastHeight, &witnessed); err != nil {
+                        // Back off exponentially while the node is unreachable or failing.
+                        delay *= 2
+                        if delay > maxBackoff {
+                                delay = maxBackoff
+                        }
+                        log.Printf("error: %v (retrying in %s)", err, delay)
+                } else {
+                        delay = pollEvery
+                }
+                time.Sleep(delay)
+        }
+}
+
+// step witnesses the current head once and refreshes the reward balance.
+func step(base string, priv *ecdsa.PrivateKey, addr types.Address, st *stats, lastHeight *uint64, witnessed *bool) error {
+        head, err := fetchHead(base)
+        if err != nil {
+                return fmt.Errorf("fetch head: %w", err)
+        }
+
+        if !*witnessed || head.Height > *lastHeight {
+                hash, err := types.ParseHash(head.Hash)
                 if err != nil {
-                        log.Printf("error fetching head: %v", err)
-                        time.Sleep(time.Duration(*interval) * time.Second)
-                        continue
+                        return fmt.Errorf("bad head hash %q: %w", head.Hash, err)
                 }
+                fmt.Printf("\n[HEAD] height=%d hash=%s\n", head.Height, head.Hash)
 
-                if h.Header.Height > lastHeight {
-                        fmt.Printf("\n[HEAD] new block height=%d ts=%d\n", h.Header.Height, h.Header.Timestamp)
+                w, err := types.SignWitness(priv, head.Height, hash)
+                if err != nil {
+                        return fmt.Errorf("sign witness: %w", err)
+                }
+                accepted, reason, err := submitWitness(base, w)
+                if err != nil {
+                        return fmt.Errorf("submit witness: %w", err)
+                }
+                if accepted {
+                        st.accepted++
+                        fmt.Printf("[WITNESS] accepted for height %d\n", head.Height)
+                } else {
+                        st.rejected++
+                        fmt.Printf("[WITNESS] rejected for height %d: %s\n", head.Height, reason)
+                }
+                *lastHeight = head.Height
+                *witnessed = true
+        }
 
-                        // Create a pseudo header hash (height + timestamp) to sign as witness
-                        hash := headerHashMock(h.Header.Height, h.Header.Timestamp)
+        bal, err := fetchBalance(base, addr)
+        if err != nil {
+                return fmt.Errorf("fetch balance: %w", err)
+        }
+        if st.startBalance == nil {
+                st.startBalance = bal
+                st.lastBalance = bal
+        }
+        if bal.Cmp(st.lastBalance) != 0 {
+                earned := new(big.Int).Sub(bal, st.startBalance)
+                fmt.Printf("[REWARD] balance=%s earned=%s (accepted=%d rejected=%d)\n",
+                        bal.String(), earned.String(), st.accepted, st.rejected)
+                st.lastBalance = bal
+        }
+        return nil
+}
 
-                        sig, err := crypto.Sign(hash[:], privKey)
-                        if err != nil {
-                                log.Printf("failed to sign witness: %v", err)
-                        } else {
-                                fmt.Printf("Witness signature for height %d:\n", h.Header.Height)
-                                fmt.Printf("  hash: %s\n", hex.EncodeToString(hash[:]))
-                                fmt.Printf("  sig : %s\n", hex.EncodeToString(sig))
-                        }
+func fetchHead(base string) (*headResponse, error) {
+        resp, err := http.Get(base + "/chain/head")
+        if err != nil {
+                return nil, err
+        }
+        defer resp.Body.Close()
 
-                        lastHeight = h.Header.Height
-                }
+        if resp.StatusCode != http.StatusOK {
+                data, _ := io.ReadAll(resp.Body)
+                return nil, fmt.Errorf("rpc status %d: %s", resp.StatusCode, string(data))
+        }
 
-                time.Sleep(time.Duration(*interval) * time.Second)
+        var out headResponse
+        if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+                return nil, err
         }
+        return &out, nil
 }
 
-// headerHashMock builds a 32-byte hash from height + timestamp.
-// later you can replace this with real block header hash pulled via RPC.
-func headerHashMock(height uint64, ts int64) types.Hash {
-        var h types.Hash
-        // use big.Int to pack height and ts deterministically
-        b := new(big.Int)
-        b.Lsh(b.SetUint64(height), 32)
-        b.Add(b, big.NewInt(ts))
-        buf := b.Bytes()
-        copy(h[32-len(buf):], buf)
-        return h
+// submitWitness posts w. A 400 response is a rejection of the witness
+// itself and is reported with its reason rather than as an error.
+func submitWitness(base string, w *types.Witness) (bool, string, error) {
+        body, err := json.Marshal(w)
+        if err != nil {
+                return false, "", err
+        }
+        resp, err := http.Post(base+"/witness/submit", "application/json", bytes.NewReader(body))
+        if err != nil {
+                return false, "", err
+        }
+        defer resp.Body.Close()
+
+        data, _ := io.ReadAll(resp.Body)
+        switch {
+        case resp.StatusCode == http.StatusOK:
+                return true, "", nil
+        case resp.StatusCode == http.StatusBadRequest:
+                return false, strings.TrimSpace(string(data)), nil
+        default:
+                return false, "", fmt.Errorf("rpc status %d: %s", resp.StatusCode, string(data))
+        }
 }
 
-func fetchHead(rpcURL string) (*headResponse, error) {
-        resp, err := http.Get(strings.TrimRight(rpcURL, "/") + "/chain/head")
+func fetchBalance(base string, addr types.Address) (*big.Int, error) {
+        resp, err := http.Get(base + "/account/balance?address=" + addr.String())
         if err != nil {
                 return nil, err
         }
@@ -108,11 +195,15 @@ func fetchHead(rpcURL string) (*headResponse, error) {
                 return nil, fmt.Errorf("rpc status %d: %s", resp.StatusCode, string(data))
         }
 
-        var out headResponse
+        var out balanceResponse
         if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
                 return nil, err
         }
-        return &out, nil
+        bal, ok := new(big.Int).SetString(out.Balance, 10)
+        if !ok {
+                return nil, fmt.Errorf("bad balance %q", out.Balance)
+        }
+        return bal, nil
 }
 
 func loadPrivateKey(hexStr string) (*ecdsa.PrivateKey, types.Address, error) {
@@ -130,4 +221,4 @@ func loadPrivateKey(hexStr string) (*ecdsa.PrivateKey, types.Address, error) {
         }
         addr := types.PubKeyToAddress(&key.PublicKey)
         return key, addr, nil
-}
\ No newline at end of file
+}
@@ -54,11 +54,10 @@ func Load() (*Config, error) {
                         return nil, fmt.Errorf("invalid MINER_ADDRESS: %w", err)
                 }
                 cfg.MinerAddress = addr
-        } else {
-                _, addr, _ := types.GenerateKey()
-                cfg.MinerAddress = addr
         }
-
+        // Without MINER_PRIVATE_KEY the node has no validator identity and
+        // does not produce blocks. No key is generated: an identity that
+        // changed on every restart could never hold stake or a slot.
         return cfg, nil
 }
 
@@ -23,6 +23,10 @@ type ChainConfig struct {
 	RewardPoolAddr string `json:"reward_pool"`
 	BaseReward     string `json:"base_reward"`
 
+	RewardReductionInterval uint64 `json:"reward_reduction_interval"`
+	RewardReductionPercent  uint64 `json:"reward_reduction_percent"`
+	BaseFeeToPool           bool   `json:"base_fee_to_pool"`
+
 	ShareTier1 uint64 `json:"share_t1"`
 	ShareTier2 uint64 `json:"share_t2"`
 	ShareTier3 uint64 `json:"share_t3"`
@@ -30,6 +34,17 @@ type ChainConfig struct {
 
 	ValidatorCount int    `json:"validator_count"`
 	MinStake       string `json:"min_stake"`
+	EpochLength    uint64 `json:"epoch_length"`
+
+	UnbondingPeriod uint64 `json:"unbonding_period"`
+	SlashPercent    uint64 `json:"slash_percent"`
+	JailPeriod      uint64 `json:"jail_period"`
+
+	PriceBump uint64 `json:"price_bump"`
+
+	FreezeAdmins     []string `json:"freeze_admins"`
+	FreezeThreshold  uint64   `json:"freeze_threshold"`
+	GovernanceFreeze bool     `json:"governance_freeze"`
 }
 
 type NodeConfig struct {
@@ -57,12 +72,23 @@ func DefaultConfig() Config {
 			BlockGasLimit:  10000000,
 			BaseReward:     "5000000000000000000",
 			RewardPoolAddr: "0x0000000000000000000000000000000000000099",
+
+			RewardReductionInterval: 4_000_000,
+			RewardReductionPercent:  50,
+
 			ShareTier1:     60,
 			ShareTier2:     25,
 			ShareTier3:     5,
 			SharePool:      10,
 			ValidatorCount: 21,
 			MinStake:       "1000000000000000000000",
+			EpochLength:    100,
+
+			UnbondingPeriod: 1000,
+			SlashPercent:    5,
+			JailPeriod:      1000,
+
+			PriceBump: 10,
 		},
 		Node: NodeConfig{
 			MinerAddress:     "",
@@ -116,8 +142,39 @@ func applyEnvOverrides(cfg *Config) error {
 	if err := parseUint("KRYPPER_SHARE_T2", &cfg.Chain.ShareTier2); err != nil { return err }
 	if err := parseUint("KRYPPER_SHARE_T3", &cfg.Chain.ShareTier3); err != nil { return err }
 	if err := parseUint("KRYPPER_SHARE_POOL", &cfg.Chain.SharePool); err != nil { return err }
+	if err := parseUint("KRYPPER_EPOCH_LENGTH", &cfg.Chain.EpochLength); err != nil { return err }
+	if err := parseUint("KRYPPER_UNBONDING_PERIOD", &cfg.Chain.UnbondingPeriod); err != nil { return err }
+	if err := parseUint("KRYPPER_REWARD_REDUCTION_INTERVAL", &cfg.Chain.RewardReductionInterval); err != nil { return err }
+	if err := parseUint("KRYPPER_REWARD_REDUCTION_PERCENT", &cfg.Chain.RewardReductionPercent); err != nil { return err }
+	if err := parseUint("KRYPPER_SLASH_PERCENT", &cfg.Chain.SlashPercent); err != nil { return err }
+	if err := parseUint("KRYPPER_JAIL_PERIOD", &cfg.Chain.JailPeriod); err != nil { return err }
+	if err := parseUint("KRYPPER_PRICE_BUMP", &cfg.Chain.PriceBump); err != nil { return err }
+	if err := parseUint("KRYPPER_FREEZE_THRESHOLD", &cfg.Chain.FreezeThreshold); err != nil { return err }
 
 	if v := os.Getenv("KRYPPER_REWARD_POOL"); v != "" { cfg.Chain.RewardPoolAddr = v }
+	if v := os.Getenv("KRYPPER_BASE_FEE_TO_POOL"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("KRYPPER_BASE_FEE_TO_POOL invalid boolean value: %s", v)
+		}
+		cfg.Chain.BaseFeeToPool = b
+	}
+	if v := os.Getenv("KRYPPER_GOVERNANCE_FREEZE"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("KRYPPER_GOVERNANCE_FREEZE invalid boolean value: %s", v)
+		}
+		cfg.Chain.GovernanceFreeze = b
+	}
+	if v := os.Getenv("KRYPPER_FREEZE_ADMINS"); v != "" {
+		var admins []string
+		for _, p := range strings.Split(v, ",") {
+			if trimmed := strings.TrimSpace(p); trimmed != "" {
+				admins = append(admins, trimmed)
+			}
+		}
+		cfg.Chain.FreezeAdmins = admins
+	}
 	if v := os.Getenv("KRYPPER_MINER"); v != "" { cfg.Node.MinerAddress = v }
 	if v := os.Getenv("KRYPPER_RPC"); v != "" { cfg.Node.RPCListenAddress = v }
 	if v := os.Getenv("KRYPPER_P2P"); v != "" { cfg.Node.P2PListenAddress = v }
@@ -152,9 +209,30 @@ func validate(c Config) error {
 	if _, ok := new(big.Int).SetString(c.Chain.BaseReward, 10); !ok {
 		return fmt.Errorf("invalid base_reward format: %s", c.Chain.BaseReward)
 	}
+	if c.Chain.RewardReductionPercent > 100 {
+		return errors.New("reward_reduction_percent must be <= 100")
+	}
 	if _, ok := new(big.Int).SetString(c.Chain.MinStake, 10); !ok {
 		return fmt.Errorf("invalid min_stake format: %s", c.Chain.MinStake)
 	}
+	if c.Chain.ValidatorCount <= 0 {
+		return errors.New("validator_count must be > 0")
+	}
+	if c.Chain.EpochLength == 0 {
+		return errors.New("epoch_length must be > 0")
+	}
+	if c.Chain.SlashPercent > 100 {
+		return errors.New("slash_percent must be <= 100")
+	}
+
+	if c.Chain.FreezeThreshold > uint64(len(c.Chain.FreezeAdmins)) {
+		return errors.New("freeze_threshold exceeds number of freeze_admins")
+	}
+	for _, a := range c.Chain.FreezeAdmins {
+		if !strings.HasPrefix(a, "0x") {
+			return fmt.Errorf("freeze admin address invalid format: %s", a)
+		}
+	}
 
 	if !strings.HasPrefix(c.Chain.RewardPoolAddr, "0x") {
 		return errors.New("reward_pool address invalid format")
@@ -8,18 +8,27 @@ import (
 	"fmt"
 	"log"
 	"math/big"
+	"os"
+	"os/signal"
 	"strings"
+	"syscall"
 
 	"krypper-chain/config"
+	"krypper-chain/core"
 	"krypper-chain/node"
 	"krypper-chain/p2p"
 	"krypper-chain/rpc"
+	"krypper-chain/storage"
 	"krypper-chain/types"
+
+	gethcrypto "github.com/ethereum/go-ethereum/crypto"
 )
 
 func main() {
 	rpcPortFlag := flag.String("port", "", "RPC port (overrides RPC_PORT env)")
 	peerListFlag := flag.String("peers", "", "Comma separated peer URLs (overrides PEER_LIST env)")
+	configFlag := flag.String("config", "", "Path to chain/node config json")
+	dataDirFlag := flag.String("datadir", "", "Chain data directory (overrides data_dir)")
 	flag.Parse()
 
 	fmt.Println("=== KRYPPER NODE START ===")
@@ -38,49 +47,133 @@ func main() {
 
 	cfg.Print()
 
-	state := types.NewStateDB()
-	mempool := types.NewMempool(state)
+	coreCfg, err := core.LoadConfig(*configFlag)
+	if err != nil {
+		log.Fatal("CONFIG ERROR:", err)
+	}
+	if *dataDirFlag != "" {
+		coreCfg.Node.DataDir = *dataDirFlag
+	}
+
+	db, err := storage.NewLevelDB(coreCfg.Node.DataDir)
+	if err != nil {
+		log.Fatal("DATABASE:", err)
+	}
+	fmt.Println("Data dir:", coreCfg.Node.DataDir)
+
+	state, err := types.NewStateDBFromDB(db)
+	if err != nil {
+		log.Fatal("STATE:", err)
+	}
+	mempool := types.NewMempool(state, cfg.NetworkID)
+	mempool.SetPriceBump(coreCfg.Chain.PriceBump)
 
 	minerAddr := cfg.MinerAddress
 	fmt.Println("Miner:", minerAddr.String())
 
-	var rewardPool types.Address
-	rewardPool[0] = 0xAA
+	rewardPool, err := types.ParseAddress(coreCfg.Chain.RewardPoolAddr)
+	if err != nil {
+		log.Fatal("CONFIG ERROR: reward_pool:", err)
+	}
+
+	var freezeAdmins []types.Address
+	for _, a := range coreCfg.Chain.FreezeAdmins {
+		addr, err := types.ParseAddress(a)
+		if err != nil {
+			log.Fatal("CONFIG ERROR: freeze_admins:", err)
+		}
+		freezeAdmins = append(freezeAdmins, addr)
+	}
+
+	minStake, _ := new(big.Int).SetString(coreCfg.Chain.MinStake, 10)
+	baseReward, _ := new(big.Int).SetString(coreCfg.Chain.BaseReward, 10)
 
 	chainCfg := types.ChainConfig{
-		ChainID:    cfg.NetworkID,
-		RewardPool: rewardPool,
-		ShareTier1: 70,
-		ShareTier2: 20,
-		ShareTier3: 5,
-		SharePool:  5,
+		ChainID:        cfg.NetworkID,
+		RewardPool:     rewardPool,
+		ShareTier1:     coreCfg.Chain.ShareTier1,
+		ShareTier2:     coreCfg.Chain.ShareTier2,
+		ShareTier3:     coreCfg.Chain.ShareTier3,
+		SharePool:      coreCfg.Chain.SharePool,
+		ValidatorCount: coreCfg.Chain.ValidatorCount,
+		MinStake:       minStake,
+		EpochLength:    coreCfg.Chain.EpochLength,
+
+		UnbondingPeriod: coreCfg.Chain.UnbondingPeriod,
+		SlashPercent:    coreCfg.Chain.SlashPercent,
+		JailPeriod:      coreCfg.Chain.JailPeriod,
+
+		BaseReward:              baseReward,
+		RewardReductionInterval: coreCfg.Chain.RewardReductionInterval,
+		RewardReductionPercent:  coreCfg.Chain.RewardReductionPercent,
+		BaseFeeToPool:           coreCfg.Chain.BaseFeeToPool,
+
+		MaxBlockSize: coreCfg.Chain.MaxBlockSize,
+
+		FreezeAdmins:     freezeAdmins,
+		FreezeThreshold:  coreCfg.Chain.FreezeThreshold,
+		GovernanceFreeze: coreCfg.Chain.GovernanceFreeze,
 	}
 
 	exec := types.NewExecutor(state, chainCfg)
 	chain := types.NewBlockchain(state, exec)
+	chain.SetMempool(mempool)
+
+	resumed, err := chain.LoadHead()
+	if err != nil {
+		log.Fatal("CHAIN:", err)
+	}
 
-	var gAddress types.Address
-	gAddress[0] = 0x11
+	if resumed {
+		head := chain.Head()
+		fmt.Printf("RESUMED: height=%d hash=%s\n", head.Header.Height, head.Hash())
+	} else {
+		var gAddress types.Address
+		gAddress[0] = 0x11
+
+		amount := new(big.Int).Mul(big.NewInt(1_000_000), big.NewInt(1e18))
+		state.Mint(gAddress, amount)
+
+		// Optional genesis file: extra allocations and staked validators.
+		if _, err := os.Stat(coreCfg.Node.GenesisFile); err == nil {
+			g, err := core.LoadGenesis(coreCfg.Node.GenesisFile)
+			if err != nil {
+				log.Fatal("GENESIS:", err)
+			}
+			validators, err := core.ApplyGenesis(state, coreCfg, g)
+			if err != nil {
+				log.Fatal("GENESIS:", err)
+			}
+			fmt.Println("Genesis validators:", len(validators))
+		}
 
-	amount := new(big.Int).Mul(big.NewInt(1_000_000), big.NewInt(1e18))
-	state.Mint(gAddress, amount)
+		genHeader := &types.BlockHeader{
+			ChainID:    chainCfg.ChainID,
+			ParentHash: types.ZeroHash(),
+			Height:     0,
+			Timestamp:  1700000000,
+			StateRoot:  state.StateRoot(),
+			TxRoot:     types.ZeroHash(),
+			GasLimit:   coreCfg.Chain.BlockGasLimit,
+			BaseFee:    types.InitialBaseFee,
+			Proposer:   gAddress,
+		}
 
-	genHeader := &types.BlockHeader{
-		ParentHash: types.ZeroHash(),
-		Height:     0,
-		Timestamp:  1700000000,
-		StateRoot:  state.StateRoot(),
-		TxRoot:     types.ZeroHash(),
-		GasLimit:   30_000_000,
-		Proposer:   gAddress,
-	}
+		genesis := types.NewBlock(genHeader, []*types.Transaction{})
+		if err := chain.AddBlock(genesis); err != nil {
+			log.Fatal("GENESIS:", err)
+		}
 
-	genesis := types.NewBlock(genHeader, []*types.Transaction{})
-	if err := chain.AddBlock(genesis); err != nil {
-		log.Fatal("GENESIS:", err)
+		fmt.Println("GENESIS OK:", genesis.Hash())
 	}
 
-	fmt.Println("GENESIS OK:", genesis.Hash())
+	mempool.SetBaseFee(types.CalcBaseFee(chain.Head().Header))
+
+	if vs := chain.ValidatorSet(); vs.Len() == 0 {
+		fmt.Println("No staked Tier-2 validators: finality disabled")
+	} else {
+		fmt.Println("Active Tier-2 validators:", vs.Len())
+	}
 
 	peers := []string{}
 	if cfg.PeerList != "" {
@@ -89,6 +182,16 @@ func main() {
 	_ = p2p.NewManager(peers)
 
 	n := node.NewNode(chain, state, mempool, exec, minerAddr)
+	n.GasLimitTarget = coreCfg.Chain.BlockGasLimit
+	if len(cfg.MinerPrivKey) > 0 {
+		minerKey, err := gethcrypto.ToECDSA(cfg.MinerPrivKey)
+		if err != nil {
+			log.Fatal("MINER KEY:", err)
+		}
+		n.SetMinerKey(minerKey)
+	} else {
+		log.Println("WARNING: MINER_PRIVATE_KEY is not set: this node has no validator identity and will not produce blocks")
+	}
 	n.Start()
 
 	server := rpc.NewServer(n)
@@ -101,5 +204,14 @@ func main() {
 	}()
 
 	fmt.Println("NODE RUNNING")
-	select {}
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM)
+	<-sig
+
+	n.Stop()
+	if err := db.Close(); err != nil {
+		log.Println("DATABASE CLOSE:", err)
+	}
+	fmt.Println("NODE STOPPED")
 }
@@ -4,6 +4,8 @@
 package node
 
 import (
+        "crypto/ecdsa"
+        "errors"
         "log"
         "sync"
         "time"
@@ -21,14 +23,26 @@ type Node struct {
 
         MinerAddress types.Address
 
+        // minerKey signs produced block headers. Without it the node
+        // follows the chain but does not propose.
+        minerKey *ecdsa.PrivateKey
+
         // Tier-3 mobile witnesses
-        witnessQueue []types.Witness
+        witnesses *types.WitnessPool
 
         // Tier-2 validator votes, keyed by block height
         validatorVotes map[uint64][]types.ValidatorVote
 
+        // evidence txs submitted by this node, keyed by offence, so an
+        // offence is not paid for twice while its tx is pooled
+        evidenceTxs map[types.Hash]types.Hash
+
         Running   bool
         BlockTime time.Duration
+
+        // GasLimitTarget is the block gas limit the node moves toward,
+        // within the per-block adjustment bound (0 → keep the parent's).
+        GasLimitTarget uint64
 }
 
 func NewNode(
@@ -45,11 +59,22 @@ func NewNode(
                 Executor:       exec,
                 MinerAddress:   minerAddr,
                 BlockTime:      5 * time.Second,
-                witnessQueue:   make([]types.Witness, 0),
+                witnesses:      types.NewWitnessPool(),
                 validatorVotes: make(map[uint64][]types.ValidatorVote),
+                evidenceTxs:    make(map[types.Hash]types.Hash),
         }
 }
 
+// SetMinerKey sets the key used to sign proposed blocks and makes its
+// address the miner address.
+func (n *Node) SetMinerKey(priv *ecdsa.PrivateKey) {
+        n.mu.Lock()
+        defer n.mu.Unlock()
+
+        n.minerKey = priv
+        n.MinerAddress = types.PrivateKeyToAddress(priv)
+}
+
 func (n *Node) Start() {
         n.mu.Lock()
         if n.Running {
@@ -76,25 +101,39 @@ func (n *Node) IsRunning() bool {
         return n.Running
 }
 
-// AddWitness enqueues a Tier-3 witness for the next blocks.
-func (n *Node) AddWitness(w types.Witness) {
-        n.mu.Lock()
-        defer n.mu.Unlock()
-
-        n.witnessQueue = append(n.witnessQueue, w)
+// AddWitness verifies a Tier-3 witness and adds it to the witness pool,
+// expiring witnesses that fell behind the current head.
+func (n *Node) AddWitness(w types.Witness) error {
+        if err := types.VerifyWitness(&w, n.Chain); err != nil {
+                return err
+        }
+        return n.witnesses.Add(w, n.Chain.Head().Header.Height)
 }
 
 // AddValidatorVote stores a Tier-2 validator vote for the current head block.
+// Votes are only accepted from the active validator set of the voted epoch.
 func (n *Node) AddValidatorVote(v types.ValidatorVote) error {
         n.mu.Lock()
         defer n.mu.Unlock()
 
         // Stateless verify
-        _, err := types.VerifyValidatorVote(&v)
+        voter, err := types.VerifyValidatorVote(&v)
         if err != nil {
                 return err
         }
 
+        // Only members of the validator set active at the voted height count.
+        if !n.Chain.ValidatorSetAt(v.Height).Contains(voter) {
+                return types.ErrNotActiveValidator
+        }
+
+        // Count the vote towards finality of the voted block.
+        if finalized, err := n.Chain.AddVote(&v); err != nil {
+                return err
+        } else if finalized {
+                log.Printf("[node] block finalized: height=%d hash=%s\n", v.Height, v.Block.String())
+        }
+
         head := n.Chain.Head()
         if head == nil {
                 return nil
@@ -117,6 +156,63 @@ func (n *Node) AddValidatorVote(v types.ValidatorVote) error {
         return nil
 }
 
+// evidenceGasLimit is the gas limit of evidence txs the node submits.
+const evidenceGasLimit = 200_000
+
+// evidenceTipBlocks is how many recent blocks the evidence tx tip follows.
+const evidenceTipBlocks = 20
+
+// SubmitEvidence checks ev against the head state and submits it to the
+// mempool in a tx signed by the miner key. It returns the tx hash.
+// Evidence the next block would reject (expired, already slashed or
+// pending, or against an offender without stake) is refused, since its
+// failed tx would still be paid for by the miner.
+func (n *Node) SubmitEvidence(ev *types.Evidence) (types.Hash, error) {
+        n.mu.Lock()
+        defer n.mu.Unlock()
+
+        key := n.minerKey
+        if key == nil {
+                return types.Hash{}, errors.New("node has no miner key to sign evidence")
+        }
+        head := n.Chain.Head()
+        if head == nil {
+                return types.Hash{}, errors.New("no head block")
+        }
+
+        _, offence, err := n.Executor.CheckEvidence(ev, head.Header.Height+1)
+        if err != nil {
+                return types.Hash{}, err
+        }
+        for o, h := range n.evidenceTxs {
+                if !n.Mempool.Has(h) {
+                        delete(n.evidenceTxs, o)
+                }
+        }
+        if _, ok := n.evidenceTxs[offence]; ok {
+                return types.Hash{}, types.ErrDuplicateEvidence
+        }
+
+        // Pay the going rate like krypcli does; a zero fee cap is always
+        // below the base fee and would never be included.
+        chainID := n.Executor.Config().ChainID
+        tip := n.Chain.SuggestTip(evidenceTipBlocks)
+        nonce := n.Mempool.NextNonce(types.PrivateKeyToAddress(key))
+        tx, err := types.NewEvidenceTx(chainID, nonce, ev, nil, evidenceGasLimit)
+        if err != nil {
+                return types.Hash{}, err
+        }
+        tx.SetDynamicFee(types.SuggestFeeCap(types.CalcBaseFee(head.Header), tip), tip)
+        if err := types.SignTransaction(tx, key); err != nil {
+                return types.Hash{}, err
+        }
+        if err := n.Mempool.AddTx(tx); err != nil {
+                return types.Hash{}, err
+        }
+        n.evidenceTxs[offence] = tx.Hash()
+        return tx.Hash(), nil
+}
+
 // miningLoop periodically attempts to build and commit new blocks from the mempool.
 func (n *Node) miningLoop() {
         ticker := time.NewTicker(n.BlockTime)
@@ -129,6 +225,11 @@ func (n *Node) miningLoop() {
 
                 <-ticker.C
 
+                // Only propose in our own slot; leave the mempool untouched otherwise.
+                if !n.isOwnSlot() {
+                        continue
+                }
+
                 // Select transactions from mempool
                 txs := n.Mempool.PopForBlock(100)
                 if len(txs) == 0 {
@@ -141,6 +242,33 @@ func (n *Node) miningLoop() {
         }
 }
 
+// returnToMempool re-submits txs popped for a block that did not include
+// them. The mempool re-validates them against the current state.
+func (n *Node) returnToMempool(txs []*types.Transaction) {
+        for _, tx := range txs {
+                _ = n.Mempool.AddTx(tx)
+        }
+}
+
+// isOwnSlot reports whether this node may propose the next block in the
+// round open now. If the scheduled proposer stays offline, later rounds
+// hand the height to other validators.
+func (n *Node) isOwnSlot() bool {
+        n.mu.RLock()
+        defer n.mu.RUnlock()
+
+        if n.minerKey == nil {
+                return false
+        }
+        head := n.Chain.Head()
+        if head == nil {
+                return false
+        }
+        round := types.RoundAt(head.Header.Timestamp, time.Now().Unix())
+        proposer, scheduled := n.Chain.ProposerAt(head.Header.Height+1, round)
+        return !scheduled || proposer == n.MinerAddress
+}
+
 // createAndSubmitBlock builds a new block with selected txs and submits it to the chain.
 func (n *Node) createAndSubmitBlock(txs []*types.Transaction) error {
         n.mu.Lock()
@@ -152,63 +280,129 @@ func (n *Node) createAndSubmitBlock(txs []*types.Transaction) error {
                 return nil
         }
 
+        parentHeight := head.Header.Height
+        votes := n.validatorVotes[parentHeight]
+
         // --- pick witness (Tier-3) ---
+        // The chain selects it among the witnesses committed two epochs
+        // back; its signature for the parent goes into the header if we
+        // have it. The witnesses of the parent are committed for later.
         var witnessAddr types.Address
-        if len(n.witnessQueue) > 0 {
-                w := n.witnessQueue[0]
+        var witnessSig []byte
+        n.witnesses.Prune(parentHeight)
+        candidates, w, ok := n.Chain.ChooseWitness(head.Hash(), parentHeight+1, n.witnesses.Candidates(parentHeight, head.Hash()))
+        if ok {
                 witnessAddr = w.Address
-                // remove used witness
-                n.witnessQueue = n.witnessQueue[1:]
+                witnessSig = w.Signature
         }
 
         // --- pick validator (Tier-2) ---
+        // Its vote signature for the parent goes into the header so the
+        // attestation counts towards fork choice.
         var validatorAddr types.Address
-        parentHeight := head.Header.Height
-        if votes, ok := n.validatorVotes[parentHeight]; ok && len(votes) > 0 {
+        var validatorSig []byte
+        if len(votes) > 0 {
                 // for now: pick the first vote
                 validatorAddr = votes[0].Voter
+                validatorSig = votes[0].SigBytes()
                 // clear stored votes for this height to avoid unbounded growth
                 delete(n.validatorVotes, parentHeight)
         }
 
+        // timestamps must increase strictly
+        timestamp := time.Now().Unix()
+        if timestamp <= head.Header.Timestamp {
+                timestamp = head.Header.Timestamp + 1
+        }
+
         // build header skeleton
         header := &types.BlockHeader{
-                ParentHash: head.Hash(),
-                Height:     head.Header.Height + 1,
-                Timestamp:  time.Now().Unix(),
-                Proposer:   n.MinerAddress,
-                Validator:  validatorAddr,
-                Witness:    witnessAddr,
-                GasLimit:   30_000_000,
+                ChainID:           n.Executor.Config().ChainID,
+                ParentHash:        head.Hash(),
+                Height:            head.Header.Height + 1,
+                Round:             types.RoundAt(head.Header.Timestamp, timestamp),
+                Timestamp:         timestamp,
+                Proposer:          n.MinerAddress,
+                Validator:         validatorAddr,
+                ValidatorSig:      validatorSig,
+                Witness:           witnessAddr,
+                WitnessSig:        witnessSig,
+                WitnessCandidates: candidates,
+                GasLimit:          types.CalcGasLimit(head.Header.GasLimit, n.GasLimitTarget),
+                BaseFee:           types.CalcBaseFee(head.Header),
         }
 
-        // dry-run execution to compute StateRoot
+        // dry-run execution to compute StateRoot; txs that fail are
+        // excluded and the rest of the block is still built
         snap := n.State.Snapshot()
 
         // ensure the executor knows which block header is currently being executed
         n.Executor.SetCurrentHeader(header)
 
-        for _, tx := range txs {
+        included := make([]*types.Transaction, 0, len(txs))
+        var leftover []*types.Transaction // still valid, back to the mempool
+        failed := make(map[types.Address]bool)
+        maxSize := int(n.Executor.Config().MaxBlockSize)
+        size := 0
+        for i, tx := range txs {
+                from, err := types.RecoverTxSender(tx)
+                if err != nil {
+                        log.Printf("[node] excluded tx %s: %v\n", tx.Hash().String(), err)
+                        continue
+                }
+                // later nonces of a sender whose tx failed cannot run now
+                if failed[from] {
+                        leftover = append(leftover, tx)
+                        continue
+                }
+
+                // stop once the block is full
+                txSize := types.TxSize(tx)
+                if tx.GasLimit > header.GasLimit-n.Executor.GasUsed() ||
+                        (maxSize > 0 && size+txSize > maxSize) {
+                        leftover = append(leftover, txs[i:]...)
+                        break
+                }
+
                 if _, err := n.Executor.ExecuteTx(tx); err != nil {
-                        // revert and drop this block attempt
-                        n.State.RevertToSnapshot(snap)
-                        return err
+                        log.Printf("[node] excluded tx %s: %v\n", tx.Hash().String(), err)
+                        failed[from] = true
+                        continue
                 }
+                included = append(included, tx)
+                size += txSize
         }
 
+        // mint the block subsidy on top, exactly as AddBlock will
+        n.Executor.ApplyBlockReward()
+
+        header.GasUsed = n.Executor.GasUsed()
+        header.ReceiptRoot = types.ReceiptRoot(n.Executor.BlockReceipts())
         header.StateRoot = n.State.StateRoot()
 
         // revert dry-run; Blockchain.AddBlock will run execution again atomically
         n.State.RevertToSnapshot(snap)
 
+        if len(included) == 0 {
+                n.returnToMempool(leftover)
+                return errors.New("no executable transactions")
+        }
+
         // finalize block
-        block := types.NewBlock(header, txs)
+        block := types.NewBlock(header, included)
         block.ComputeTxRoot()
 
+        if err := types.SignHeader(header, n.minerKey); err != nil {
+                n.returnToMempool(append(included, leftover...))
+                return err
+        }
+
         // submit to chain (this will do a real execution + state root check + commit)
         if err := n.Chain.AddBlock(block); err != nil {
+                n.returnToMempool(append(included, leftover...))
                 return err
         }
+        n.returnToMempool(leftover)
 
         log.Printf("[node] new block committed: height=%d hash=%s\n", block.Header.Height, block.Hash().String())
         return nil
@@ -0,0 +1,111 @@
+// SPDX-License-Identifier: MIT
+// Dev: KryperAI
+
+package node
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+	"time"
+
+	"krypper-chain/types"
+)
+
+func newTestNode(t *testing.T, rich ...types.Address) *Node {
+	t.Helper()
+	state := types.NewStateDB()
+	exec := types.NewExecutor(state, types.ChainConfig{ChainID: 1})
+	chain := types.NewBlockchain(state, exec)
+	for _, a := range rich {
+		state.Mint(a, big.NewInt(1e18))
+	}
+	genesis := types.NewBlock(&types.BlockHeader{
+		ChainID:   1,
+		Timestamp: time.Now().Unix() - 60,
+		StateRoot: state.StateRoot(),
+		GasLimit:  30_000_000,
+		BaseFee:   big.NewInt(0),
+	}, nil)
+	if err := chain.AddBlock(genesis); err != nil {
+		t.Fatal(err)
+	}
+	pool := types.NewMempool(state, 1)
+	chain.SetMempool(pool)
+
+	key, addr, err := types.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := NewNode(chain, state, pool, exec, addr)
+	n.SetMinerKey(key)
+	return n
+}
+
+func transfer(t *testing.T, key *ecdsa.PrivateKey, chainID, nonce uint64) *types.Transaction {
+	t.Helper()
+	tx := types.NewTransferTx(chainID, nonce, types.Address{1}, big.NewInt(1), big.NewInt(1), types.TxGas, nil)
+	if err := types.SignTransaction(tx, key); err != nil {
+		t.Fatal(err)
+	}
+	return tx
+}
+
+func TestBlockSkipsFailingTx(t *testing.T) {
+	goodKey, good, _ := types.GenerateKey()
+	badKey, bad, _ := types.GenerateKey()
+	n := newTestNode(t, good, bad)
+
+	ok := transfer(t, goodKey, 1, 0)
+	failing := transfer(t, badKey, 2, 0) // wrong chain
+	after := transfer(t, badKey, 1, 1)   // cannot run without nonce 0
+	if err := n.createAndSubmitBlock([]*types.Transaction{failing, after, ok}); err != nil {
+		t.Fatal(err)
+	}
+
+	head := n.Chain.Head()
+	if head.Header.Height != 1 || len(head.Transactions) != 1 || head.Transactions[0].Hash() != ok.Hash() {
+		t.Fatal("block does not hold exactly the executable tx")
+	}
+	// The later nonce goes back to the mempool; the failed tx does not.
+	if n.Mempool.Get(bad, 1) == nil || n.Mempool.Get(bad, 0) != nil {
+		t.Fatal("leftover not returned to the mempool")
+	}
+}
+
+func TestBlockWithoutExecutableTxs(t *testing.T) {
+	badKey, bad, _ := types.GenerateKey()
+	n := newTestNode(t, bad)
+
+	if err := n.createAndSubmitBlock([]*types.Transaction{transfer(t, badKey, 2, 0)}); err == nil {
+		t.Fatal("empty block built")
+	}
+	if n.Chain.Head().Header.Height != 0 {
+		t.Fatal("head moved")
+	}
+}
+
+func TestSubmitEvidenceRefusesUnpunishable(t *testing.T) {
+	n := newTestNode(t)
+	n.State.Mint(n.MinerAddress, big.NewInt(1e18))
+	vk, offender, _ := types.GenerateKey()
+	va, _ := types.SignValidatorVote(vk, 1, 0, types.Hash{1})
+	vb, _ := types.SignValidatorVote(vk, 1, 0, types.Hash{2})
+	vc, _ := types.SignValidatorVote(vk, 1, 0, types.Hash{3})
+
+	if _, err := n.SubmitEvidence(types.NewDuplicateVoteEvidence(va, vb)); err != types.ErrNothingToSlash {
+		t.Fatalf("offender without stake: %v", err)
+	}
+
+	n.State.SetStake(offender, big.NewInt(1000))
+	if _, err := n.SubmitEvidence(types.NewDuplicateVoteEvidence(va, vb)); err != nil {
+		t.Fatal(err)
+	}
+	// Another pair for the same offence is refused while the first is pooled.
+	if _, err := n.SubmitEvidence(types.NewDuplicateVoteEvidence(va, vc)); err != types.ErrDuplicateEvidence {
+		t.Fatalf("pending offence: %v", err)
+	}
+	if n.Mempool.Count() != 1 {
+		t.Fatalf("%d evidence txs pooled", n.Mempool.Count())
+	}
+}
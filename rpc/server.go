@@ -5,8 +5,11 @@ package rpc
 
 import (
 	"encoding/json"
+	"errors"
 	"log"
+	"math/big"
 	"net/http"
+	"strconv"
 
 	"krypper-chain/node"
 	"krypper-chain/types"
@@ -25,12 +28,22 @@ func (s *Server) Start(addr string) error {
 
 	// Public RPC
 	mux.HandleFunc("/tx/send", s.handleSendTx)
+	mux.HandleFunc("/tx/pending", s.handlePendingTx)
+	mux.HandleFunc("/tx/receipt", s.handleTxReceipt)
 	mux.HandleFunc("/account/balance", s.handleBalance)
+	mux.HandleFunc("/account/proof", s.handleAccountProof)
+	mux.HandleFunc("/account/freeze", s.handleFreezeStatus)
+	mux.HandleFunc("/account/multisig", s.handleMultisig)
 	mux.HandleFunc("/chain/head", s.handleHead)
+	mux.HandleFunc("/chain/finalized", s.handleFinalized)
+	mux.HandleFunc("/chain/supply", s.handleSupply)
+	mux.HandleFunc("/fees/suggest", s.handleSuggestFees)
 
 	// Validator / Witness
 	mux.HandleFunc("/witness/submit", s.handleSubmitWitness)
 	mux.HandleFunc("/validator/vote", s.handleSubmitVote)
+	mux.HandleFunc("/validator/stake", s.handleValidatorStake)
+	mux.HandleFunc("/evidence/submit", s.handleSubmitEvidence)
 
 	log.Println("RPC Active", addr)
 	return http.ListenAndServe(addr, mux)
@@ -49,10 +62,16 @@ func (s *Server) handleSendTx(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Replay protection: reject txs signed for another network up front.
+	if err := tx.CheckChainID(s.node.Executor.Config().ChainID); err != nil {
+		http.Error(w, err.Error(), 400)
+		return
+	}
+
 	// REAL METHOD WE HAVE IN SYSTEM ✔
 	from, err := types.RecoverTxSender(&tx)
 	if err != nil {
-		http.Error(w, "invalid signature", 400)
+		http.Error(w, "invalid signature: "+err.Error(), 400)
 		return
 	}
 
@@ -71,6 +90,57 @@ func (s *Server) handleSendTx(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// handlePendingTx returns the pooled tx of ?address= with ?nonce=.
+func (s *Server) handlePendingTx(w http.ResponseWriter, r *http.Request) {
+	addr, err := types.ParseAddress(r.URL.Query().Get("address"))
+	if err != nil {
+		http.Error(w, "invalid address", 400)
+		return
+	}
+	nonce, err := strconv.ParseUint(r.URL.Query().Get("nonce"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid nonce", 400)
+		return
+	}
+
+	tx := s.node.Mempool.Get(addr, nonce)
+	if tx == nil {
+		http.Error(w, "tx not pending", 404)
+		return
+	}
+	json.NewEncoder(w).Encode(tx)
+}
+
+// handleTxReceipt returns the receipt of the canonical tx ?hash=.
+func (s *Server) handleTxReceipt(w http.ResponseWriter, r *http.Request) {
+	h, err := types.ParseHash(r.URL.Query().Get("hash"))
+	if err != nil {
+		http.Error(w, "invalid hash", 400)
+		return
+	}
+
+	receipt, l, err := s.node.Chain.GetReceipt(h)
+	if errors.Is(err, types.ErrReceiptNotFound) {
+		http.Error(w, "receipt not found", 404)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]any{
+		"txHash":    receipt.TxHash.String(),
+		"blockHash": l.BlockHash.String(),
+		"height":    l.Height,
+		"index":     l.Index,
+		"success":   receipt.Success,
+		"gasUsed":   receipt.GasUsed,
+		"code":      receipt.Code,
+		"error":     receipt.Error,
+	})
+}
+
 // ============ ACCOUNT =============
 func (s *Server) handleBalance(w http.ResponseWriter, r *http.Request) {
 	addrHex := r.URL.Query().Get("address")
@@ -86,6 +156,97 @@ func (s *Server) handleBalance(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// handleFreezeStatus returns whether ?address= is frozen and its freeze
+// history.
+func (s *Server) handleFreezeStatus(w http.ResponseWriter, r *http.Request) {
+	addr, err := types.ParseAddress(r.URL.Query().Get("address"))
+	if err != nil {
+		http.Error(w, "invalid address", 400)
+		return
+	}
+
+	history := s.node.State.FreezeHistory(addr)
+	if history == nil {
+		history = []types.FreezeRecord{}
+	}
+	json.NewEncoder(w).Encode(map[string]any{
+		"address": addr.String(),
+		"frozen":  s.node.State.IsFrozen(addr),
+		"history": history,
+	})
+}
+
+// handleMultisig returns the signer set and threshold of the multisig
+// account ?address=.
+func (s *Server) handleMultisig(w http.ResponseWriter, r *http.Request) {
+	addr, err := types.ParseAddress(r.URL.Query().Get("address"))
+	if err != nil {
+		http.Error(w, "invalid address", 400)
+		return
+	}
+
+	p := s.node.State.MultisigPolicy(addr)
+	if p == nil {
+		http.Error(w, "not a multisig account", 404)
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]any{
+		"address":   addr.String(),
+		"signers":   p.Signers,
+		"threshold": p.Threshold,
+		"nonce":     s.node.State.GetNonce(addr),
+	})
+}
+
+// handleAccountProof returns balance, nonce and a Merkle proof for an
+// account against the state root of the block at ?height= (default: head).
+func (s *Server) handleAccountProof(w http.ResponseWriter, r *http.Request) {
+	addr, err := types.ParseAddress(r.URL.Query().Get("address"))
+	if err != nil {
+		http.Error(w, "invalid address", 400)
+		return
+	}
+
+	block := s.node.Chain.Head()
+	if hs := r.URL.Query().Get("height"); hs != "" {
+		height, err := strconv.ParseUint(hs, 10, 64)
+		if err != nil {
+			http.Error(w, "invalid height", 400)
+			return
+		}
+		block = s.node.Chain.GetBlockByHeight(height)
+	}
+	if block == nil {
+		http.Error(w, "block not found", 404)
+		return
+	}
+
+	proof, err := s.node.State.ProveAccount(block.Header.StateRoot, addr)
+	if err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+
+	acc, err := types.VerifyAccountProof(block.Header.StateRoot, proof)
+	if err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+	if acc == nil {
+		acc = types.NewAccount(addr)
+	}
+
+	json.NewEncoder(w).Encode(map[string]any{
+		"address":   addr.String(),
+		"height":    block.Header.Height,
+		"blockHash": block.Hash().String(),
+		"stateRoot": block.Header.StateRoot.String(),
+		"balance":   acc.Balance.String(),
+		"nonce":     acc.Nonce,
+		"proof":     proof,
+	})
+}
+
 // ============ HEAD =============
 func (s *Server) handleHead(w http.ResponseWriter, r *http.Request) {
 	h := s.node.Chain.Head()
@@ -96,6 +257,70 @@ func (s *Server) handleHead(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+func (s *Server) handleFinalized(w http.ResponseWriter, r *http.Request) {
+	f := s.node.Chain.FinalizedHead()
+	if f == nil {
+		http.Error(w, "no finalized block", 404)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]any{
+		"height": f.Header.Height,
+		"hash":   f.Hash().String(),
+	})
+}
+
+// handleSupply reports the total supply after the block at ?height=
+// (default head) and the subsidy minted and fees burned by that block.
+func (s *Server) handleSupply(w http.ResponseWriter, r *http.Request) {
+	height := s.node.Chain.Head().Header.Height
+	if hs := r.URL.Query().Get("height"); hs != "" {
+		n, err := strconv.ParseUint(hs, 10, 64)
+		if err != nil {
+			http.Error(w, "invalid height", 400)
+			return
+		}
+		height = n
+	}
+
+	is, err := s.node.Chain.IssuanceAt(height)
+	if err != nil {
+		http.Error(w, err.Error(), 404)
+		return
+	}
+	cfg := s.node.Executor.Config()
+
+	json.NewEncoder(w).Encode(map[string]any{
+		"height":          height,
+		"supply":          is.Supply.String(),
+		"reward":          is.Reward.String(),
+		"burned":          is.Burned.String(),
+		"scheduledReward": cfg.BlockReward(height).String(),
+		"nextReward":      cfg.BlockReward(height + 1).String(),
+	})
+}
+
+// ============ FEES =============
+
+// suggestFeeBlocks is how many recent blocks tip suggestions look at.
+const suggestFeeBlocks = 20
+
+// handleSuggestFees returns the next block's base fee and suggested fee
+// caps. The max fee leaves room for the base fee to double.
+func (s *Server) handleSuggestFees(w http.ResponseWriter, r *http.Request) {
+	head := s.node.Chain.Head()
+	baseFee := types.CalcBaseFee(head.Header)
+	tip := s.node.Chain.SuggestTip(suggestFeeBlocks)
+	maxFee := types.SuggestFeeCap(baseFee, tip)
+
+	json.NewEncoder(w).Encode(map[string]any{
+		"height":               head.Header.Height + 1,
+		"baseFee":              baseFee.String(),
+		"maxPriorityFeePerGas": tip.String(),
+		"maxFeePerGas":         maxFee.String(),
+	})
+}
+
 // ============ WITNESS =============
 func (s *Server) handleSubmitWitness(w http.ResponseWriter, r *http.Request) {
 	var wtx types.Witness
@@ -104,7 +329,10 @@ func (s *Server) handleSubmitWitness(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	s.node.AddWitness(wtx)
+	if err := s.node.AddWitness(wtx); err != nil {
+		http.Error(w, "invalid witness: "+err.Error(), 400)
+		return
+	}
 
 	json.NewEncoder(w).Encode(map[string]any{
 		"stored":  true,
@@ -120,9 +348,73 @@ func (s *Server) handleSubmitVote(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	s.node.AddValidatorVote(vote)
+	if err := s.node.AddValidatorVote(vote); err != nil {
+		http.Error(w, err.Error(), 400)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]any{
+		"accepted": true,
+	})
+}
+
+// handleValidatorStake reports an address's own, delegated and total stake,
+// whether it is in the active set, and its own delegations and unbonding.
+func (s *Server) handleValidatorStake(w http.ResponseWriter, r *http.Request) {
+	addr, err := types.ParseAddress(r.URL.Query().Get("address"))
+	if err != nil {
+		http.Error(w, "invalid address", 400)
+		return
+	}
+
+	st := s.node.State
+	self := st.GetStake(addr)
+	delegated := st.GetDelegatedStake(addr)
+	total := new(big.Int).Add(self, delegated)
+
+	delegations := st.GetDelegations(addr)
+	if delegations == nil {
+		delegations = []types.Delegation{}
+	}
+	unbonding := st.GetUnbonding(addr)
+	if unbonding == nil {
+		unbonding = []types.UnbondingEntry{}
+	}
+
+	json.NewEncoder(w).Encode(map[string]any{
+		"address":     addr.String(),
+		"stake":       self.String(),
+		"delegated":   delegated.String(),
+		"total":       total.String(),
+		"active":      s.node.Chain.ValidatorSet().Contains(addr),
+		"jailed":      st.IsJailed(addr, s.node.Chain.Head().Header.Height+1),
+		"delegations": delegations,
+		"unbonding":   unbonding,
+	})
+}
+
+// handleSubmitEvidence accepts evidence of a double-signing validator and
+// submits it on chain in a tx signed by this node.
+func (s *Server) handleSubmitEvidence(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "POST only", 405)
+		return
+	}
+
+	var ev types.Evidence
+	if err := json.NewDecoder(r.Body).Decode(&ev); err != nil {
+		http.Error(w, "invalid evidence json", 400)
+		return
+	}
+
+	txHash, err := s.node.SubmitEvidence(&ev)
+	if err != nil {
+		http.Error(w, err.Error(), 400)
+		return
+	}
 
 	json.NewEncoder(w).Encode(map[string]any{
 		"accepted": true,
+		"txHash":   txHash.String(),
 	})
-}
\ No newline at end of file
+}
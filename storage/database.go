@@ -0,0 +1,46 @@
+// SPDX-License-Identifier: MIT
+// Dev: KryperAI
+
+package storage
+
+import "errors"
+
+// ErrNotFound is returned by Get when the key does not exist.
+var ErrNotFound = errors.New("storage: not found")
+
+// Reader is the read side of a key-value store.
+type Reader interface {
+	Has(key []byte) (bool, error)
+	Get(key []byte) ([]byte, error)
+}
+
+// Writer is the write side of a key-value store.
+type Writer interface {
+	Put(key, value []byte) error
+	Delete(key []byte) error
+}
+
+// Iterator walks keys in ascending order. Callers must call Release.
+type Iterator interface {
+	Next() bool
+	Key() []byte
+	Value() []byte
+	Error() error
+	Release()
+}
+
+// Batch buffers writes until Write is called, then applies them atomically.
+type Batch interface {
+	Writer
+	Write() error
+	Reset()
+}
+
+// Database is the pluggable key-value backend used for chain and state data.
+type Database interface {
+	Reader
+	Writer
+	NewBatch() Batch
+	NewIterator(prefix []byte) Iterator
+	Close() error
+}
@@ -0,0 +1,141 @@
+// SPDX-License-Identifier: MIT
+// Dev: KryperAI
+
+package storage
+
+import (
+	"errors"
+	"testing"
+)
+
+// testDatabases opens one instance of every backend.
+func testDatabases(t *testing.T) map[string]Database {
+	t.Helper()
+	ldb, err := NewLevelDB(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ldb.Close() })
+	return map[string]Database{
+		"memory":  NewMemoryDB(),
+		"leveldb": ldb,
+	}
+}
+
+func TestDatabasePutGetDelete(t *testing.T) {
+	for name, db := range testDatabases(t) {
+		t.Run(name, func(t *testing.T) {
+			if _, err := db.Get([]byte("k")); !errors.Is(err, ErrNotFound) {
+				t.Fatalf("missing key: %v", err)
+			}
+			if err := db.Put([]byte("k"), []byte("v")); err != nil {
+				t.Fatal(err)
+			}
+			if v, err := db.Get([]byte("k")); err != nil || string(v) != "v" {
+				t.Fatalf("get: %q, %v", v, err)
+			}
+			if ok, err := db.Has([]byte("k")); err != nil || !ok {
+				t.Fatalf("has: %v, %v", ok, err)
+			}
+			if err := db.Delete([]byte("k")); err != nil {
+				t.Fatal(err)
+			}
+			if ok, _ := db.Has([]byte("k")); ok {
+				t.Fatal("key present after delete")
+			}
+		})
+	}
+}
+
+func TestDatabaseBatch(t *testing.T) {
+	for name, db := range testDatabases(t) {
+		t.Run(name, func(t *testing.T) {
+			db.Put([]byte("old"), []byte("1"))
+
+			b := db.NewBatch()
+			b.Put([]byte("a"), []byte("1"))
+			b.Put([]byte("b"), []byte("2"))
+			b.Delete([]byte("old"))
+			if ok, _ := db.Has([]byte("a")); ok {
+				t.Fatal("batch applied before Write")
+			}
+			if err := b.Write(); err != nil {
+				t.Fatal(err)
+			}
+			if v, _ := db.Get([]byte("b")); string(v) != "2" {
+				t.Fatal("batch put lost")
+			}
+			if ok, _ := db.Has([]byte("old")); ok {
+				t.Fatal("batch delete lost")
+			}
+
+			// A reset batch writes nothing.
+			b.Reset()
+			b.Put([]byte("c"), []byte("3"))
+			b.Reset()
+			if err := b.Write(); err != nil {
+				t.Fatal(err)
+			}
+			if ok, _ := db.Has([]byte("c")); ok {
+				t.Fatal("reset batch still written")
+			}
+		})
+	}
+}
+
+func TestDatabaseIterator(t *testing.T) {
+	for name, db := range testDatabases(t) {
+		t.Run(name, func(t *testing.T) {
+			for _, k := range []string{"p3", "x1", "p1", "p2"} {
+				db.Put([]byte(k), []byte("v"+k))
+			}
+			it := db.NewIterator([]byte("p"))
+			defer it.Release()
+			var got []string
+			for it.Next() {
+				if string(it.Value()) != "v"+string(it.Key()) {
+					t.Fatalf("value of %s: %s", it.Key(), it.Value())
+				}
+				got = append(got, string(it.Key()))
+			}
+			if err := it.Error(); err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != 3 || got[0] != "p1" || got[1] != "p2" || got[2] != "p3" {
+				t.Fatalf("iterated %v", got)
+			}
+		})
+	}
+}
+
+func TestLevelDBReopen(t *testing.T) {
+	dir := t.TempDir()
+	db, err := NewLevelDB(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.Put([]byte("k"), []byte("v"))
+	if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	db, err = NewLevelDB(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if v, err := db.Get([]byte("k")); err != nil || string(v) != "v" {
+		t.Fatalf("get after reopen: %q, %v", v, err)
+	}
+}
+
+func TestMemoryDBClosed(t *testing.T) {
+	db := NewMemoryDB()
+	db.Close()
+	if err := db.Put([]byte("k"), []byte("v")); err == nil {
+		t.Fatal("put on closed database")
+	}
+	if _, err := db.Get([]byte("k")); err == nil || errors.Is(err, ErrNotFound) {
+		t.Fatalf("get on closed database: %v", err)
+	}
+}
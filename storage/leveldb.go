@@ -0,0 +1,85 @@
+// SPDX-License-Identifier: MIT
+// Dev: KryperAI
+
+package storage
+
+import (
+	"errors"
+
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+// LevelDB is the embedded, pure-Go on-disk Database backend.
+type LevelDB struct {
+	db *leveldb.DB
+}
+
+// NewLevelDB opens (or creates) a LevelDB store in the given directory.
+func NewLevelDB(dir string) (*LevelDB, error) {
+	if dir == "" {
+		return nil, errors.New("storage: empty leveldb path")
+	}
+	db, err := leveldb.OpenFile(dir, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &LevelDB{db: db}, nil
+}
+
+func (l *LevelDB) Has(key []byte) (bool, error) {
+	return l.db.Has(key, nil)
+}
+
+func (l *LevelDB) Get(key []byte) ([]byte, error) {
+	v, err := l.db.Get(key, nil)
+	if errors.Is(err, leveldb.ErrNotFound) {
+		return nil, ErrNotFound
+	}
+	return v, err
+}
+
+func (l *LevelDB) Put(key, value []byte) error {
+	return l.db.Put(key, value, nil)
+}
+
+func (l *LevelDB) Delete(key []byte) error {
+	return l.db.Delete(key, nil)
+}
+
+func (l *LevelDB) NewBatch() Batch {
+	return &levelBatch{db: l.db, b: new(leveldb.Batch)}
+}
+
+func (l *LevelDB) NewIterator(prefix []byte) Iterator {
+	return l.db.NewIterator(util.BytesPrefix(prefix), nil)
+}
+
+func (l *LevelDB) Close() error {
+	return l.db.Close()
+}
+
+// -------------------------------------------------------------
+
+type levelBatch struct {
+	db *leveldb.DB
+	b  *leveldb.Batch
+}
+
+func (b *levelBatch) Put(key, value []byte) error {
+	b.b.Put(key, value)
+	return nil
+}
+
+func (b *levelBatch) Delete(key []byte) error {
+	b.b.Delete(key)
+	return nil
+}
+
+func (b *levelBatch) Write() error {
+	return b.db.Write(b.b, nil)
+}
+
+func (b *levelBatch) Reset() {
+	b.b.Reset()
+}
@@ -0,0 +1,184 @@
+// SPDX-License-Identifier: MIT
+// Dev: KryperAI
+
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"sort"
+	"sync"
+)
+
+// MemoryDB is an in-memory Database, used for tests and ephemeral nodes.
+type MemoryDB struct {
+	mu     sync.RWMutex
+	data   map[string][]byte
+	closed bool
+}
+
+func NewMemoryDB() *MemoryDB {
+	return &MemoryDB{data: make(map[string][]byte)}
+}
+
+func (m *MemoryDB) Has(key []byte) (bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.closed {
+		return false, errors.New("storage: closed")
+	}
+	_, ok := m.data[string(key)]
+	return ok, nil
+}
+
+func (m *MemoryDB) Get(key []byte) ([]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.closed {
+		return nil, errors.New("storage: closed")
+	}
+	v, ok := m.data[string(key)]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return bytes.Clone(v), nil
+}
+
+func (m *MemoryDB) Put(key, value []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return errors.New("storage: closed")
+	}
+	m.data[string(key)] = bytes.Clone(value)
+	return nil
+}
+
+func (m *MemoryDB) Delete(key []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return errors.New("storage: closed")
+	}
+	delete(m.data, string(key))
+	return nil
+}
+
+func (m *MemoryDB) NewBatch() Batch {
+	return &memBatch{db: m}
+}
+
+// NewIterator returns a snapshot iterator over all keys with the given prefix.
+func (m *MemoryDB) NewIterator(prefix []byte) Iterator {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	keys := make([]string, 0)
+	for k := range m.data {
+		if bytes.HasPrefix([]byte(k), prefix) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	values := make([][]byte, len(keys))
+	for i, k := range keys {
+		values[i] = bytes.Clone(m.data[k])
+	}
+	return &memIterator{keys: keys, values: values, pos: -1}
+}
+
+func (m *MemoryDB) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.closed = true
+	return nil
+}
+
+// Len returns the number of stored keys.
+func (m *MemoryDB) Len() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return len(m.data)
+}
+
+// -------------------------------------------------------------
+
+type memOp struct {
+	key    []byte
+	value  []byte
+	delete bool
+}
+
+type memBatch struct {
+	db  *MemoryDB
+	ops []memOp
+}
+
+func (b *memBatch) Put(key, value []byte) error {
+	b.ops = append(b.ops, memOp{key: bytes.Clone(key), value: bytes.Clone(value)})
+	return nil
+}
+
+func (b *memBatch) Delete(key []byte) error {
+	b.ops = append(b.ops, memOp{key: bytes.Clone(key), delete: true})
+	return nil
+}
+
+func (b *memBatch) Write() error {
+	b.db.mu.Lock()
+	defer b.db.mu.Unlock()
+	if b.db.closed {
+		return errors.New("storage: closed")
+	}
+	for _, op := range b.ops {
+		if op.delete {
+			delete(b.db.data, string(op.key))
+			continue
+		}
+		b.db.data[string(op.key)] = op.value
+	}
+	return nil
+}
+
+func (b *memBatch) Reset() {
+	b.ops = b.ops[:0]
+}
+
+// -------------------------------------------------------------
+
+type memIterator struct {
+	keys   []string
+	values [][]byte
+	pos    int
+}
+
+func (it *memIterator) Next() bool {
+	if it.pos+1 >= len(it.keys) {
+		it.pos = len(it.keys)
+		return false
+	}
+	it.pos++
+	return true
+}
+
+func (it *memIterator) Key() []byte {
+	if it.pos < 0 || it.pos >= len(it.keys) {
+		return nil
+	}
+	return []byte(it.keys[it.pos])
+}
+
+func (it *memIterator) Value() []byte {
+	if it.pos < 0 || it.pos >= len(it.keys) {
+		return nil
+	}
+	return it.values[it.pos]
+}
+
+func (it *memIterator) Error() error { return nil }
+
+func (it *memIterator) Release() {
+	it.keys = nil
+	it.values = nil
+}
@@ -10,6 +10,8 @@ import (
 	"math/big"
 )
 
+var ErrInsufficientBalance = errors.New("insufficient balance")
+
 // Account represents a single world-state account.
 type Account struct {
 	Address     Address  `json:"address"`
@@ -18,6 +20,22 @@ type Account struct {
 	CodeHash    Hash     `json:"codeHash"`
 	StorageRoot Hash     `json:"storageRoot"`
 	Frozen      bool     `json:"frozen"`
+
+	// Staking (see staking.go)
+	Stake          *big.Int         `json:"stake"`          // self-bonded validator stake
+	DelegatedStake *big.Int         `json:"delegatedStake"` // stake delegated to this validator
+	Delegations    []Delegation     `json:"delegations,omitempty"`
+	Unbonding      []UnbondingEntry `json:"unbonding,omitempty"`
+
+	// Slashing (see slashing.go)
+	JailedUntil uint64 `json:"jailedUntil,omitempty"` // excluded from validator selection below this height
+	Slashed     []Hash `json:"slashed,omitempty"`     // sorted keys of punished offences
+
+	// Freezing (see freeze.go)
+	FreezeLog []FreezeRecord `json:"freezeLog,omitempty"` // freeze changes, oldest first
+
+	// Multisig control (see multisig.go); nil for single-key accounts
+	Multisig *MultisigPolicy `json:"multisig,omitempty"`
 }
 
 // NewAccount initializes a zeroed account for a given address.
@@ -29,6 +47,9 @@ func NewAccount(addr Address) *Account {
 		CodeHash:    ZeroHash(),
 		StorageRoot: ZeroHash(),
 		Frozen:      false,
+
+		Stake:          big.NewInt(0),
+		DelegatedStake: big.NewInt(0),
 	}
 }
 
@@ -38,19 +59,41 @@ func (a *Account) Copy() *Account {
 		return nil
 	}
 
-	balCopy := big.NewInt(0)
-	if a.Balance != nil {
-		balCopy.Set(a.Balance)
-	}
-
-	return &Account{
+	out := &Account{
 		Address:     a.Address,
-		Balance:     balCopy,
+		Balance:     copyBig(a.Balance),
 		Nonce:       a.Nonce,
 		CodeHash:    a.CodeHash,
 		StorageRoot: a.StorageRoot,
 		Frozen:      a.Frozen,
+
+		Stake:          copyBig(a.Stake),
+		DelegatedStake: copyBig(a.DelegatedStake),
+		JailedUntil:    a.JailedUntil,
+	}
+	if len(a.Slashed) > 0 {
+		out.Slashed = append([]Hash(nil), a.Slashed...)
+	}
+	for _, d := range a.Delegations {
+		out.Delegations = append(out.Delegations, Delegation{Validator: d.Validator, Amount: copyBig(d.Amount)})
+	}
+	for _, u := range a.Unbonding {
+		out.Unbonding = append(out.Unbonding, UnbondingEntry{Amount: copyBig(u.Amount), ReleaseHeight: u.ReleaseHeight})
+	}
+	for _, r := range a.FreezeLog {
+		r.Approvers = append([]Address(nil), r.Approvers...)
+		out.FreezeLog = append(out.FreezeLog, r)
+	}
+	out.Multisig = a.Multisig.copy()
+	return out
+}
+
+// copyBig returns a copy of n, treating nil as zero.
+func copyBig(n *big.Int) *big.Int {
+	if n == nil {
+		return big.NewInt(0)
 	}
+	return new(big.Int).Set(n)
 }
 
 func (a *Account) AddBalance(amount *big.Int) error {
@@ -72,7 +115,7 @@ func (a *Account) SubBalance(amount *big.Int) error {
 		return errors.New("amount must be non-negative")
 	}
 	if a.Balance.Cmp(amount) < 0 {
-		return errors.New("insufficient balance")
+		return ErrInsufficientBalance
 	}
 	a.Balance.Sub(a.Balance, amount)
 	return nil
@@ -118,6 +161,68 @@ func (a *Account) Hash() Hash {
 		h.Write([]byte{0})
 	}
 
+	// Staking
+	writeBig(h, a.Stake)
+	writeBig(h, a.DelegatedStake)
+	binary.BigEndian.PutUint64(buf[:], uint64(len(a.Delegations)))
+	h.Write(buf[:])
+	for _, d := range a.Delegations {
+		h.Write(d.Validator[:])
+		writeBig(h, d.Amount)
+	}
+	binary.BigEndian.PutUint64(buf[:], uint64(len(a.Unbonding)))
+	h.Write(buf[:])
+	for _, u := range a.Unbonding {
+		writeBig(h, u.Amount)
+		binary.BigEndian.PutUint64(buf[:], u.ReleaseHeight)
+		h.Write(buf[:])
+	}
+
+	// Slashing
+	binary.BigEndian.PutUint64(buf[:], a.JailedUntil)
+	h.Write(buf[:])
+	binary.BigEndian.PutUint64(buf[:], uint64(len(a.Slashed)))
+	h.Write(buf[:])
+	for _, k := range a.Slashed {
+		h.Write(k[:])
+	}
+
+	// Freeze history
+	binary.BigEndian.PutUint64(buf[:], uint64(len(a.FreezeLog)))
+	h.Write(buf[:])
+	for _, r := range a.FreezeLog {
+		binary.BigEndian.PutUint64(buf[:], r.Height)
+		h.Write(buf[:])
+		if r.Frozen {
+			h.Write([]byte{1})
+		} else {
+			h.Write([]byte{0})
+		}
+		h.Write([]byte{byte(r.Authority)})
+		binary.BigEndian.PutUint64(buf[:], uint64(len(r.Reason)))
+		h.Write(buf[:])
+		h.Write([]byte(r.Reason))
+		binary.BigEndian.PutUint64(buf[:], uint64(len(r.Approvers)))
+		h.Write(buf[:])
+		for _, a := range r.Approvers {
+			h.Write(a[:])
+		}
+	}
+
+	// Multisig policy
+	if a.Multisig != nil {
+		h.Write([]byte{1})
+		binary.BigEndian.PutUint64(buf[:], a.Multisig.Threshold)
+		h.Write(buf[:])
+		binary.BigEndian.PutUint64(buf[:], uint64(len(a.Multisig.Signers)))
+		h.Write(buf[:])
+		for _, s := range a.Multisig.Signers {
+			h.Write(s[:])
+		}
+	} else {
+		h.Write([]byte{0})
+	}
+
 	var out Hash
 	copy(out[:], h.Sum(nil))
 	return out
@@ -5,6 +5,7 @@ package types
 
 import (
         "encoding/hex"
+        "encoding/json"
         "errors"
         "strings"
 
@@ -38,6 +39,30 @@ func ParseAddress(s string) (Address, error) {
         return addr, nil
 }
 
+// MarshalText encodes the address as 0x prefixed hex (used by JSON).
+func (a Address) MarshalText() ([]byte, error) {
+        return []byte(a.String()), nil
+}
+
+// UnmarshalText decodes a 0x prefixed hex address.
+func (a *Address) UnmarshalText(text []byte) error {
+        parsed, err := ParseAddress(string(text))
+        if err != nil {
+                return err
+        }
+        *a = parsed
+        return nil
+}
+
+// UnmarshalJSON decodes a hex string, or the array of byte values
+// addresses were encoded as before they implemented MarshalText.
+func (a *Address) UnmarshalJSON(input []byte) error {
+        if isJSONArray(input) {
+                return json.Unmarshal(input, (*[AddressLength]byte)(a))
+        }
+        return unmarshalJSONText(input, a.UnmarshalText)
+}
+
 // HexToAddress is an alias for ParseAddress.
 func HexToAddress(s string) (Address, error) {
         return ParseAddress(s)
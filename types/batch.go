@@ -0,0 +1,114 @@
+// SPDX-License-Identifier: MIT
+// Dev: KryperAI
+
+package types
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+)
+
+// A batch transaction (TxTypeBatch) pays many recipients under one
+// signature and nonce. Its Data lists the transfers and its Value is their
+// total, so balance checks treat it like a single transfer. Execution is
+// atomic: if any transfer fails, none is applied. Each recipient costs
+// TxBatchRecipientGas on top of the base transaction gas.
+
+// MaxBatchTransfers caps the number of transfers in one batch.
+const MaxBatchTransfers = 1000
+
+var ErrInvalidBatch = errors.New("invalid batch transfer")
+
+// BatchTransfer is one (recipient, amount) pair of a batch.
+type BatchTransfer struct {
+	To     Address  `json:"to"`
+	Amount *big.Int `json:"amount"`
+}
+
+// EncodeBatch returns the canonical encoding used in tx data.
+func EncodeBatch(transfers []BatchTransfer) ([]byte, error) {
+	return json.Marshal(transfers)
+}
+
+// DecodeBatch decodes the transfers of a batch from tx data.
+func DecodeBatch(data []byte) ([]BatchTransfer, error) {
+	var transfers []BatchTransfer
+	if err := json.Unmarshal(data, &transfers); err != nil {
+		return nil, ErrInvalidBatch
+	}
+	return transfers, nil
+}
+
+// NewBatchTransferTx builds an unsigned batch transaction paying
+// transfers. Its value is set to their total.
+func NewBatchTransferTx(chainId uint64, nonce uint64, transfers []BatchTransfer, gasPrice *big.Int, gasLimit uint64) (*Transaction, error) {
+	data, err := EncodeBatch(transfers)
+	if err != nil {
+		return nil, err
+	}
+	total := big.NewInt(0)
+	for _, t := range transfers {
+		if t.Amount == nil {
+			return nil, ErrInvalidBatch
+		}
+		total.Add(total, t.Amount)
+	}
+	tx := NewTransferTx(chainId, nonce, Address{}, total, gasPrice, gasLimit, data)
+	tx.Type = TxTypeBatch
+	return tx, nil
+}
+
+// validateBatch checks that tx carries 1..MaxBatchTransfers well-formed
+// transfers adding up to its value.
+func validateBatch(tx *Transaction) error {
+	transfers, err := DecodeBatch(tx.Data)
+	if err != nil {
+		return err
+	}
+	if len(transfers) == 0 || len(transfers) > MaxBatchTransfers {
+		return ErrInvalidBatch
+	}
+	total := big.NewInt(0)
+	for _, t := range transfers {
+		if t.Amount == nil || t.Amount.Sign() < 0 {
+			return ErrInvalidBatch
+		}
+		total.Add(total, t.Amount)
+	}
+	if total.Cmp(tx.Value) != 0 {
+		return errors.New("batch value does not match transfer total")
+	}
+	return nil
+}
+
+// batchLen returns the number of transfers in a batch tx's data, or 0 if
+// it does not decode.
+func batchLen(tx *Transaction) int {
+	transfers, err := DecodeBatch(tx.Data)
+	if err != nil {
+		return 0
+	}
+	return len(transfers)
+}
+
+// applyBatch moves the batch total out of from and pays every recipient.
+// The caller reverts all of it if any step fails.
+func (e *Executor) applyBatch(from Address, tx *Transaction) error {
+	transfers, err := DecodeBatch(tx.Data)
+	if err != nil {
+		return err
+	}
+	if err := e.state.SubBalance(from, tx.Value); err != nil {
+		return err
+	}
+	for _, t := range transfers {
+		if t.Amount.Sign() == 0 {
+			continue
+		}
+		if err := e.state.AddBalance(t.To, t.Amount); err != nil {
+			return err
+		}
+	}
+	return nil
+}
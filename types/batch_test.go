@@ -0,0 +1,61 @@
+// SPDX-License-Identifier: MIT
+// Dev: KryperAI
+
+package types
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestBatchTransfer(t *testing.T) {
+	key, rich := newTestKey(t)
+	c := newTestChain(t, rich)
+	r1, r2 := Address{1}, Address{2}
+	tx, err := NewBatchTransferTx(1, 0, []BatchTransfer{{r1, big.NewInt(10)}, {r2, big.NewInt(20)}}, big.NewInt(1), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx.GasLimit = IntrinsicGas(tx)
+	if err := SignTransaction(tx, key); err != nil {
+		t.Fatal(err)
+	}
+	if IntrinsicGas(tx) <= TxGas+2*TxBatchRecipientGas {
+		t.Fatal("batch data not charged")
+	}
+	if err := c.pool.AddTx(tx); err != nil {
+		t.Fatal(err)
+	}
+	c.mine(t, []*Transaction{tx}, Address{}, 1)
+	if c.state.GetBalance(r1).Int64() != 10 || c.state.GetBalance(r2).Int64() != 20 {
+		t.Fatal("recipients not paid")
+	}
+}
+
+func TestBatchTransferIsAtomic(t *testing.T) {
+	key, rich := newTestKey(t)
+	c := newTestChain(t, rich)
+	r1, r2 := Address{1}, Address{2}
+	tooMuch := new(big.Int).Mul(c.state.GetBalance(rich), big.NewInt(2))
+	tx, _ := NewBatchTransferTx(1, 0, []BatchTransfer{{r1, big.NewInt(1)}, {r2, tooMuch}}, big.NewInt(1), 100_000)
+	if err := SignTransaction(tx, key); err != nil {
+		t.Fatal(err)
+	}
+	c.mine(t, []*Transaction{tx}, Address{}, 1)
+
+	r, _, err := c.chain.GetReceipt(tx.Hash())
+	if err != nil || r.Success || r.Code != ReceiptInsufficientBalance {
+		t.Fatalf("receipt %+v, %v", r, err)
+	}
+	if c.state.GetAccount(r1) != nil {
+		t.Fatal("first transfer not reverted")
+	}
+}
+
+func TestBatchValueMustMatch(t *testing.T) {
+	tx, _ := NewBatchTransferTx(1, 0, []BatchTransfer{{Address{1}, big.NewInt(10)}}, big.NewInt(1), 100_000)
+	tx.Value = big.NewInt(5)
+	if tx.ValidateBasic() == nil {
+		t.Fatal("value mismatch accepted")
+	}
+}
@@ -6,45 +6,94 @@ package types
 import (
         "crypto/sha256"
         "encoding/binary"
+        "errors"
+        "math/big"
 )
 
 // BlockHeader supports Tier1/Tier2/Tier3 consensus
 type BlockHeader struct {
-        ParentHash Hash
-        Height     uint64
-        Timestamp  int64
-        StateRoot  Hash
-        TxRoot     Hash
-        GasLimit   uint64
+        ChainID     uint64 // chain the header is signed for
+        ParentHash  Hash
+        Height      uint64
+        Round       uint64 // proposer round (see proposer.go)
+        Timestamp   int64
+        StateRoot   Hash
+        TxRoot      Hash
+        ReceiptRoot Hash // root of the block's receipts (see receipt.go)
+        GasLimit    uint64
+        GasUsed     uint64
+        BaseFee     *big.Int // fee-market base fee per gas (see fees.go)
 
         // Tier-based block production
         Proposer  Address // Tier1
         Validator Address // Tier2
         Witness   Address // Tier3
+
+        // ValidatorSig is the Tier-2 validator's vote signature for the
+        // parent (see SignValidatorVote). Empty when there is no validator.
+        ValidatorSig []byte
+
+        // WitnessSig is the Tier-3 witness's signature over the parent
+        // (see SignWitness). Empty when there is no witness.
+        WitnessSig []byte
+
+        // WitnessCandidates commits witnesses of the parent, in address
+        // order. They become candidates for Witness two epochs later, and
+        // Witness must be the one selected among them (see witnessDraw).
+        WitnessCandidates []Witness `json:",omitempty"`
+
+        // Signature is the proposer's signature over HashHeader. It is not
+        // part of the hash itself.
+        Signature Signature
 }
 
-// HashHeader returns hash of block header
+// HashHeader returns hash of block header (excluding the signature)
 func (h *BlockHeader) HashHeader() Hash {
         b := sha256.New()
         var buf [8]byte
 
+        binary.BigEndian.PutUint64(buf[:], h.ChainID)
+        b.Write(buf[:])
+
         b.Write(h.ParentHash[:])
 
         binary.BigEndian.PutUint64(buf[:], h.Height)
         b.Write(buf[:])
 
+        binary.BigEndian.PutUint64(buf[:], h.Round)
+        b.Write(buf[:])
+
         binary.BigEndian.PutUint64(buf[:], uint64(h.Timestamp))
         b.Write(buf[:])
 
         b.Write(h.StateRoot[:])
         b.Write(h.TxRoot[:])
+        b.Write(h.ReceiptRoot[:])
 
         binary.BigEndian.PutUint64(buf[:], h.GasLimit)
         b.Write(buf[:])
 
+        binary.BigEndian.PutUint64(buf[:], h.GasUsed)
+        b.Write(buf[:])
+        writeBig(b, h.BaseFee)
+
         b.Write(h.Proposer[:])
         b.Write(h.Validator[:])
+        binary.BigEndian.PutUint64(buf[:], uint64(len(h.ValidatorSig)))
+        b.Write(buf[:])
+        b.Write(h.ValidatorSig)
         b.Write(h.Witness[:])
+        binary.BigEndian.PutUint64(buf[:], uint64(len(h.WitnessSig)))
+        b.Write(buf[:])
+        b.Write(h.WitnessSig)
+        binary.BigEndian.PutUint64(buf[:], uint64(len(h.WitnessCandidates)))
+        b.Write(buf[:])
+        for _, w := range h.WitnessCandidates {
+                b.Write(w.Address[:])
+                binary.BigEndian.PutUint64(buf[:], uint64(len(w.Signature)))
+                b.Write(buf[:])
+                b.Write(w.Signature)
+        }
 
         var out Hash
         copy(out[:], b.Sum(nil))
@@ -76,21 +125,44 @@ func (b *Block) Hash() Hash {
 
 // ComputeTxRoot calculates merkle-like root of txs
 func (b *Block) ComputeTxRoot() {
+        b.Header.TxRoot = b.txRoot()
+}
+
+func (b *Block) txRoot() Hash {
         if len(b.Transactions) == 0 {
-                b.Header.TxRoot = ZeroHash()
-                return
+                return ZeroHash()
         }
         h := make([]Hash, 0, len(b.Transactions))
         for _, tx := range b.Transactions {
                 h = append(h, tx.Hash())
         }
-        b.Header.TxRoot = merkleFromHashes(h)
+        return merkleFromHashes(h)
 }
 
-// ValidateBasic performs basic block validation
+// ValidateBasic performs the stateless checks of a block: its tx root,
+// gas used and gas limit bounds, and every transaction's basic validity.
+// Checks against the parent and state are in headerverify.go.
 func (b *Block) ValidateBasic() error {
         if b == nil || b.Header == nil {
-                return nil
+                return errors.New("nil block header")
+        }
+        h := b.Header
+        if b.txRoot() != h.TxRoot {
+                return ErrTxRoot
+        }
+        if err := verifyGasLimit(h.GasLimit, nil); err != nil {
+                return err
+        }
+        if h.GasUsed > h.GasLimit {
+                return ErrBlockGasLimit
+        }
+        for _, tx := range b.Transactions {
+                if tx == nil {
+                        return errors.New("nil transaction")
+                }
+                if err := tx.ValidateBasic(); err != nil {
+                        return err
+                }
         }
         return nil
 }
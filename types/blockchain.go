@@ -5,28 +5,107 @@ package types
 
 import (
 	"errors"
+	"math/big"
 	"sync"
+
+	"krypper-chain/storage"
 )
 
 // Blockchain manages blocks, verifies transitions, commits state.
+// Blocks and the head pointer are persisted in the StateDB's database;
+// the maps below act as an in-memory cache in front of it.
+//
+// blocksByHash holds every known block, canonical or side chain, while
+// blocksByHeight only indexes the canonical chain. The head is the known
+// block with the greatest cumulative weight (see forkchoice.go) that does
+// not conflict with the finalized block (see finality.go).
 type Blockchain struct {
 	mu             sync.RWMutex
+	db             storage.Database
 	state          *StateDB
 	executor       *Executor
+	mempool        *Mempool
 	blocksByHash   map[Hash]*Block
 	blocksByHeight map[uint64]*Block
+	weights        map[Hash]uint64
+	issuance       map[Hash]*Issuance
+	votes          map[Hash]map[Address]struct{}
+	validatorSets  map[uint64]*ValidatorSet
+	stagedSets     map[uint64]*ValidatorSet // activated by the reorg in progress
 	head           *Block
+	finalized      *Block
 }
 
 // NewBlockchain creates a chain with the given StateDB and Executor.
 func NewBlockchain(state *StateDB, executor *Executor) *Blockchain {
-	return &Blockchain{
+	bc := &Blockchain{
+		db:             state.Database(),
 		state:          state,
 		executor:       executor,
 		blocksByHash:   make(map[Hash]*Block),
 		blocksByHeight: make(map[uint64]*Block),
+		weights:        make(map[Hash]uint64),
+		issuance:       make(map[Hash]*Issuance),
+		votes:          make(map[Hash]map[Address]struct{}),
+		validatorSets:  make(map[uint64]*ValidatorSet),
 		head:           nil,
 	}
+	executor.validators = bc.activeSetAt
+	return bc
+}
+
+// SetMempool sets the pool that is reset after new blocks and receives
+// transactions orphaned by a reorg.
+func (bc *Blockchain) SetMempool(m *Mempool) {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+	bc.mempool = m
+}
+
+// LoadHead restores the head block from the database.
+// It reports false if the database holds no chain yet.
+func (bc *Blockchain) LoadHead() (bool, error) {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+
+	h, err := ReadHeadHash(bc.db)
+	if errors.Is(err, storage.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	b, err := ReadBlock(bc.db, h)
+	if err != nil {
+		return false, err
+	}
+	if b.Hash() != h {
+		return false, errors.New("stored head hash mismatch")
+	}
+	if b.Header.StateRoot != bc.state.StateRoot() {
+		return false, errors.New("stored state does not match head state root")
+	}
+	weight, err := ReadBlockWeight(bc.db, h)
+	if err != nil {
+		return false, err
+	}
+
+	fh, err := ReadFinalizedHash(bc.db)
+	if err != nil {
+		return false, err
+	}
+	finalized, err := ReadBlock(bc.db, fh)
+	if err != nil {
+		return false, err
+	}
+
+	bc.blocksByHash[h] = b
+	bc.blocksByHeight[b.Header.Height] = b
+	bc.weights[h] = weight
+	bc.head = b
+	bc.finalized = finalized
+	return true, nil
 }
 
 // Head returns the current tip of the chain.
@@ -40,17 +119,38 @@ func (bc *Blockchain) Head() *Block {
 func (bc *Blockchain) GetBlockByHash(h Hash) *Block {
 	bc.mu.RLock()
 	defer bc.mu.RUnlock()
-	return bc.blocksByHash[h]
+	return bc.blockByHash(h)
 }
 
 // GetBlockByHeight returns a block by its height, or nil if not found.
 func (bc *Blockchain) GetBlockByHeight(height uint64) *Block {
 	bc.mu.RLock()
 	defer bc.mu.RUnlock()
-	return bc.blocksByHeight[height]
+	if b, ok := bc.blocksByHeight[height]; ok {
+		return b
+	}
+	h, err := ReadCanonicalHash(bc.db, height)
+	if err != nil {
+		return nil
+	}
+	return bc.blockByHash(h)
 }
 
-// AddBlock validates, executes, and commits a new block atomically.
+// blockByHash looks a block up in the cache, falling back to the database.
+func (bc *Blockchain) blockByHash(h Hash) *Block {
+	if b, ok := bc.blocksByHash[h]; ok {
+		return b
+	}
+	b, err := ReadBlock(bc.db, h)
+	if err != nil {
+		return nil
+	}
+	return b
+}
+
+// AddBlock validates and stores a new block. A block extending the head is
+// executed and committed directly; a block on another branch is stored as a
+// side block and triggers a reorg once its branch outweighs the head.
 func (bc *Blockchain) AddBlock(b *Block) error {
 	if b == nil {
 		return errors.New("nil block")
@@ -61,88 +161,266 @@ func (bc *Blockchain) AddBlock(b *Block) error {
 		return err
 	}
 
-	bc.mu.Lock()
-	defer bc.mu.Unlock()
+	var orphaned []*Transaction
+	err := func() error {
+		bc.mu.Lock()
+		defer bc.mu.Unlock()
 
-	// Take a global snapshot for this block.
-	blockSnap := bc.state.Snapshot()
-
-	// ------------------------------------------------------------
-	// GENESIS BLOCK (HEIGHT 0)
-	// ------------------------------------------------------------
-	if b.Header.Height == 0 {
-		if bc.head != nil {
-			bc.state.RevertToSnapshot(blockSnap)
-			return errors.New("genesis already exists")
+		if bc.blockByHash(b.Hash()) != nil {
+			return errors.New("block already known")
 		}
 
-		// Set coinbase to proposer for any potential tx fees.
-		bc.executor.SetCoinbase(b.Header.Proposer)
-
-		// Execute genesis transactions if any.
-		if len(b.Transactions) > 0 {
-			if _, err := bc.executor.ExecuteBlock(b); err != nil {
-				bc.state.RevertToSnapshot(blockSnap)
+		// ------------------------------------------------------------
+		// GENESIS BLOCK (HEIGHT 0)
+		// ------------------------------------------------------------
+		if b.Header.Height == 0 {
+			if bc.head != nil {
+				return errors.New("genesis already exists")
+			}
+			if err := bc.applyAndCommit(b, blockWeight(b.Header)); err != nil {
 				return err
 			}
+			// Genesis is final by definition; its finalized hash was
+			// written with the block.
+			bc.finalized = b
+			return nil
 		}
 
-		// Verify state root after execution.
-		finalRoot := bc.state.StateRoot()
-		if finalRoot != b.Header.StateRoot {
-			bc.state.RevertToSnapshot(blockSnap)
-			return errors.New("genesis state mismatch")
+		// ------------------------------------------------------------
+		// NORMAL BLOCK
+		// ------------------------------------------------------------
+
+		// Check parent existence.
+		parent := bc.blockByHash(b.Header.ParentHash)
+		if parent == nil {
+			return errors.New("unknown parent block")
+		}
+
+		// Check height continuity.
+		if b.Header.Height != parent.Header.Height+1 {
+			return errors.New("invalid height")
 		}
 
-		// Success: commit snapshot and index block.
-		bc.state.CommitSnapshot(blockSnap)
-		return bc.commitBlock(b)
+		// Timestamps, gas limit adjustment, size and signature.
+		if err := bc.verifyHeader(b, parent); err != nil {
+			return err
+		}
+
+		parentWeight, err := bc.weightOf(parent.Hash())
+		if err != nil {
+			return err
+		}
+		weight := parentWeight + blockWeight(b.Header)
+
+		// Fast path: extends the current head.
+		if parent.Hash() == bc.head.Hash() {
+			return bc.applyAndCommit(b, weight)
+		}
+
+		// Side chain: store it, reorg only if it is now the heaviest.
+		// Forks at or below the finalized height can never win.
+		if bc.finalized != nil && b.Header.Height <= bc.finalized.Header.Height {
+			return errors.New("block below finalized height")
+		}
+		if err := bc.verifySideBlock(b.Header, parent.Header); err != nil {
+			return err
+		}
+		if err := bc.writeSideBlock(b, weight); err != nil {
+			return err
+		}
+		headWeight, err := bc.weightOf(bc.head.Hash())
+		if err != nil {
+			return err
+		}
+		if !heavier(weight, b.Hash(), headWeight, bc.head.Hash()) {
+			return nil
+		}
+		orphaned, err = bc.reorg(b)
+		return err
+	}()
+	if err != nil {
+		return err
 	}
 
-	// ------------------------------------------------------------
-	// NORMAL BLOCK
-	// ------------------------------------------------------------
+	bc.returnToMempool(orphaned)
+	return nil
+}
 
-	// Check parent existence.
-	parent, ok := bc.blocksByHash[b.Header.ParentHash]
-	if !ok || parent == nil {
-		bc.state.RevertToSnapshot(blockSnap)
-		return errors.New("unknown parent block")
+// returnToMempool re-submits transactions orphaned by a reorg and lets the
+// mempool drop and promote txs against the new head state and base fee.
+// It must be called without bc.mu held.
+func (bc *Blockchain) returnToMempool(txs []*Transaction) {
+	bc.mu.RLock()
+	pool := bc.mempool
+	var baseFee *big.Int
+	if bc.head != nil {
+		baseFee = CalcBaseFee(bc.head.Header)
+	}
+	bc.mu.RUnlock()
+	if pool == nil {
+		return
 	}
+	pool.SetBaseFee(baseFee)
+	pool.Reset()
+	for _, tx := range txs {
+		_ = pool.AddTx(tx)
+	}
+}
+
+// applyAndCommit executes b on top of the current state, verifies the state
+// root and makes it the new head. On failure state is left untouched.
+// Caller must hold bc.mu (write lock).
+func (bc *Blockchain) applyAndCommit(b *Block, weight uint64) error {
+	// Take a global snapshot for this block.
+	blockSnap := bc.state.Snapshot()
 
-	// Check height continuity.
-	if b.Header.Height != parent.Header.Height+1 {
+	if err := bc.applyBlock(b); err != nil {
 		bc.state.RevertToSnapshot(blockSnap)
-		return errors.New("invalid height")
+		return err
 	}
 
-	// Set coinbase for fee distribution.
-	bc.executor.SetCoinbase(b.Header.Proposer)
-
-	// Execute all transactions.
-	if _, err := bc.executor.ExecuteBlock(b); err != nil {
+	// Success: persist block and state, then index it.
+	batch := bc.db.NewBatch()
+	sets := make(map[uint64]*ValidatorSet)
+	if err := bc.writeCanonicalBlock(batch, b, weight, sets); err != nil {
+		bc.state.DiscardPending()
+		bc.state.RevertToSnapshot(blockSnap)
+		return err
+	}
+	if err := batch.Write(); err != nil {
+		bc.state.DiscardPending()
 		bc.state.RevertToSnapshot(blockSnap)
 		return err
 	}
+	bc.state.MarkStored()
+	bc.state.CommitSnapshot(blockSnap)
+
+	bc.indexCanonical(b, weight)
+	for epoch, vs := range sets {
+		bc.validatorSets[epoch] = vs
+	}
+	bc.head = b
+	return nil
+}
+
+// applyBlock checks b's proposer slot and round, tiers and base fee,
+// executes its transactions, mints the block reward and checks the
+// resulting gas used, receipt root and state root. Caller must hold bc.mu
+// and revert state on error.
+func (bc *Blockchain) applyBlock(b *Block) error {
+	if b.Header.Height > 0 {
+		parent := bc.blockByHash(b.Header.ParentHash)
+		if parent == nil {
+			return errors.New("unknown parent block")
+		}
+		if err := bc.verifyProposer(b.Header, parent.Header); err != nil {
+			return err
+		}
+		if err := bc.verifyTiers(b.Header); err != nil {
+			return err
+		}
+		if b.Header.BaseFee == nil || b.Header.BaseFee.Cmp(CalcBaseFee(parent.Header)) != 0 {
+			return errors.New("invalid base fee")
+		}
+	}
+
+	// Fee distribution follows the tier addresses of this header.
+	bc.executor.SetCurrentHeader(b.Header)
+
+	// Execute all transactions, then mint the block subsidy.
+	if len(b.Transactions) > 0 {
+		if _, err := bc.executor.ExecuteBlock(b); err != nil {
+			return err
+		}
+	}
+	bc.executor.ApplyBlockReward()
+
+	if bc.executor.GasUsed() != b.Header.GasUsed {
+		return ErrGasUsed
+	}
+	if ReceiptRoot(bc.executor.BlockReceipts()) != b.Header.ReceiptRoot {
+		return ErrReceiptRoot
+	}
 
 	// Verify state root matches header.
-	finalRoot := bc.state.StateRoot()
-	if finalRoot != b.Header.StateRoot {
-		bc.state.RevertToSnapshot(blockSnap)
+	if bc.state.StateRoot() != b.Header.StateRoot {
+		if b.Header.Height == 0 {
+			return errors.New("genesis state mismatch")
+		}
 		return errors.New("state root mismatch")
 	}
+	return nil
+}
 
-	// Success: commit snapshot and index block.
-	bc.state.CommitSnapshot(blockSnap)
-	return bc.commitBlock(b)
+// writeCanonicalBlock adds b, its weight, its canonical index entry, the
+// head pointer, the dirty state, its issuance record, its receipts and any
+// validator set b activates to the batch. Activated sets are recorded in
+// sets for the caller to install once the batch is written. Genesis is
+// also recorded as finalized in the same batch, so a stored chain always
+// has a finalized block. Caller must hold bc.mu (write lock).
+func (bc *Blockchain) writeCanonicalBlock(batch storage.Batch, b *Block, weight uint64, sets map[uint64]*ValidatorSet) error {
+	h := b.Hash()
+	if err := WriteBlock(batch, b); err != nil {
+		return err
+	}
+	if err := WriteBlockWeight(batch, h, weight); err != nil {
+		return err
+	}
+	if err := WriteCanonicalHash(batch, b.Header.Height, h); err != nil {
+		return err
+	}
+	if err := WriteHeadHash(batch, h); err != nil {
+		return err
+	}
+	if b.Header.Height == 0 {
+		if err := WriteFinalizedHash(batch, h); err != nil {
+			return err
+		}
+	}
+	if err := bc.stageIssuance(batch, b); err != nil {
+		return err
+	}
+	if err := bc.stageReceipts(batch, b); err != nil {
+		return err
+	}
+	if _, err := bc.state.Commit(batch); err != nil {
+		return err
+	}
+	return bc.stageValidatorSet(batch, b, sets)
 }
 
-// commitBlock writes the block into indexes and moves head forward.
+// writeSideBlock persists a block that is not (yet) on the canonical chain.
 // Caller must hold bc.mu (write lock).
-func (bc *Blockchain) commitBlock(b *Block) error {
+func (bc *Blockchain) writeSideBlock(b *Block, weight uint64) error {
+	batch := bc.db.NewBatch()
+	if err := WriteBlock(batch, b); err != nil {
+		return err
+	}
+	if err := WriteBlockWeight(batch, b.Hash(), weight); err != nil {
+		return err
+	}
+	if err := batch.Write(); err != nil {
+		return err
+	}
+	bc.blocksByHash[b.Hash()] = b
+	bc.weights[b.Hash()] = weight
+	return nil
+}
+
+// indexCanonical records b in the in-memory indexes.
+// Caller must hold bc.mu (write lock).
+func (bc *Blockchain) indexCanonical(b *Block, weight uint64) {
 	h := b.Hash()
 	bc.blocksByHash[h] = b
-	bc.blocksByHeight[uint64(b.Header.Height)] = b
-	bc.head = b
-	return nil
-}
\ No newline at end of file
+	bc.blocksByHeight[b.Header.Height] = b
+	bc.weights[h] = weight
+}
+
+// weightOf returns the cumulative weight of a known block.
+// Caller must hold bc.mu.
+func (bc *Blockchain) weightOf(h Hash) (uint64, error) {
+	if w, ok := bc.weights[h]; ok {
+		return w, nil
+	}
+	return ReadBlockWeight(bc.db, h)
+}
@@ -0,0 +1,70 @@
+// SPDX-License-Identifier: MIT
+// Dev: KryperAI
+
+package types
+
+import (
+	"testing"
+
+	"krypper-chain/storage"
+)
+
+func TestLoadHeadResumesChain(t *testing.T) {
+	dir := t.TempDir()
+	db, err := storage.NewLevelDB(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, rich := newTestKey(t)
+	c := newTestChainOn(t, db, rich)
+	c.mine(t, []*Transaction{signedTransfer(t, key, 0, Address{1}, 5, 1)}, Address{}, 1)
+	head := c.mine(t, nil, Address{}, 2)
+	genesis := c.chain.GetBlockByHeight(0)
+	if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	db, err = storage.NewLevelDB(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	state, err := NewStateDBFromDB(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exec := NewExecutor(state, testConfig)
+	chain := NewBlockchain(state, exec)
+	resumed, err := chain.LoadHead()
+	if err != nil || !resumed {
+		t.Fatalf("resumed=%v err=%v", resumed, err)
+	}
+	if chain.Head().Hash() != head.Hash() {
+		t.Fatal("head not restored")
+	}
+	if chain.FinalizedHead().Hash() != genesis.Hash() {
+		t.Fatal("genesis not finalized after restart")
+	}
+	if chain.GetBlockByHeight(1) == nil || state.GetBalance(Address{1}).Int64() != 5 {
+		t.Fatal("chain or state lost")
+	}
+	if state.GetNonce(rich) != 1 {
+		t.Fatal("nonce lost")
+	}
+
+	// The resumed chain keeps growing.
+	r := &testChain{state: state, exec: exec, chain: chain}
+	r.mine(t, []*Transaction{signedTransfer(t, key, 1, Address{1}, 5, 1)}, Address{}, 3)
+	if state.GetBalance(Address{1}).Int64() != 10 {
+		t.Fatal("block after restart not applied")
+	}
+}
+
+func TestLoadHeadEmptyDatabase(t *testing.T) {
+	state := NewStateDB()
+	chain := NewBlockchain(state, NewExecutor(state, testConfig))
+	resumed, err := chain.LoadHead()
+	if err != nil || resumed {
+		t.Fatalf("resumed=%v err=%v", resumed, err)
+	}
+}
@@ -0,0 +1,313 @@
+// SPDX-License-Identifier: MIT
+// Dev: KryperAI
+
+package types
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+
+	"krypper-chain/storage"
+)
+
+// Database key layout:
+//
+//	"h" + hash        -> encoded BlockHeader
+//	"b" + hash        -> encoded block body (transactions)
+//	"n" + height (BE) -> canonical block hash at height
+//	"w" + hash        -> cumulative fork-choice weight of the block
+//	"v" + epoch (BE)  -> active validator set of the epoch
+//	"i" + hash        -> block issuance record (see issuance.go)
+//	"r" + hash        -> receipts of the block's transactions (see receipt.go)
+//	"l" + tx hash     -> block hash, height and index of a transaction
+//	"LastBlock"       -> hash of the current head block
+//	"LastFinalized"   -> hash of the highest finalized block
+//	"LastStateRoot"   -> state trie root of the last state commit
+//	"t" + hash        -> state trie node (see trie.go)
+var (
+	headerPrefix    = []byte("h")
+	bodyPrefix      = []byte("b")
+	canonicalPrefix = []byte("n")
+	weightPrefix    = []byte("w")
+	valSetPrefix    = []byte("v")
+	issuancePrefix  = []byte("i")
+	receiptsPrefix  = []byte("r")
+	txLookupPrefix  = []byte("l")
+	headBlockKey    = []byte("LastBlock")
+	finalizedKey    = []byte("LastFinalized")
+	headStateKey    = []byte("LastStateRoot")
+)
+
+func headerKey(h Hash) []byte {
+	return append(append([]byte{}, headerPrefix...), h[:]...)
+}
+
+func bodyKey(h Hash) []byte {
+	return append(append([]byte{}, bodyPrefix...), h[:]...)
+}
+
+func canonicalKey(height uint64) []byte {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], height)
+	return append(append([]byte{}, canonicalPrefix...), buf[:]...)
+}
+
+func weightKey(h Hash) []byte {
+	return append(append([]byte{}, weightPrefix...), h[:]...)
+}
+
+func issuanceKey(h Hash) []byte {
+	return append(append([]byte{}, issuancePrefix...), h[:]...)
+}
+
+func receiptsKey(h Hash) []byte {
+	return append(append([]byte{}, receiptsPrefix...), h[:]...)
+}
+
+func txLookupKey(h Hash) []byte {
+	return append(append([]byte{}, txLookupPrefix...), h[:]...)
+}
+
+func valSetKey(epoch uint64) []byte {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], epoch)
+	return append(append([]byte{}, valSetPrefix...), buf[:]...)
+}
+
+// WriteBlock stores the header and body of a block.
+func WriteBlock(w storage.Writer, b *Block) error {
+	if b == nil || b.Header == nil {
+		return errors.New("nil block")
+	}
+	h := b.Hash()
+
+	header, err := EncodeHeader(b.Header)
+	if err != nil {
+		return err
+	}
+	if err := w.Put(headerKey(h), header); err != nil {
+		return err
+	}
+
+	txs := b.Transactions
+	if txs == nil {
+		txs = []*Transaction{}
+	}
+	body, err := json.Marshal(txs)
+	if err != nil {
+		return err
+	}
+	return w.Put(bodyKey(h), body)
+}
+
+// ReadHeader loads a header by block hash.
+func ReadHeader(r storage.Reader, h Hash) (*BlockHeader, error) {
+	data, err := r.Get(headerKey(h))
+	if err != nil {
+		return nil, err
+	}
+	return DecodeHeader(data)
+}
+
+// ReadBlock loads a full block by hash.
+func ReadBlock(r storage.Reader, h Hash) (*Block, error) {
+	header, err := ReadHeader(r, h)
+	if err != nil {
+		return nil, err
+	}
+	data, err := r.Get(bodyKey(h))
+	if err != nil {
+		return nil, err
+	}
+	var txs []*Transaction
+	if err := json.Unmarshal(data, &txs); err != nil {
+		return nil, err
+	}
+	return NewBlock(header, txs), nil
+}
+
+// WriteCanonicalHash maps a height to the canonical block hash.
+func WriteCanonicalHash(w storage.Writer, height uint64, h Hash) error {
+	return w.Put(canonicalKey(height), h[:])
+}
+
+// ReadCanonicalHash returns the canonical block hash at a height.
+func ReadCanonicalHash(r storage.Reader, height uint64) (Hash, error) {
+	data, err := r.Get(canonicalKey(height))
+	if err != nil {
+		return Hash{}, err
+	}
+	var h Hash
+	copy(h[:], data)
+	return h, nil
+}
+
+// DeleteCanonicalHash removes the canonical mapping for a height.
+func DeleteCanonicalHash(w storage.Writer, height uint64) error {
+	return w.Delete(canonicalKey(height))
+}
+
+// WriteBlockWeight stores the cumulative fork-choice weight of a block.
+func WriteBlockWeight(w storage.Writer, h Hash, weight uint64) error {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], weight)
+	return w.Put(weightKey(h), buf[:])
+}
+
+// ReadBlockWeight returns the cumulative fork-choice weight of a block.
+func ReadBlockWeight(r storage.Reader, h Hash) (uint64, error) {
+	data, err := r.Get(weightKey(h))
+	if err != nil {
+		return 0, err
+	}
+	if len(data) != 8 {
+		return 0, errors.New("invalid block weight")
+	}
+	return binary.BigEndian.Uint64(data), nil
+}
+
+// WriteHeadHash stores the hash of the current head block.
+func WriteHeadHash(w storage.Writer, h Hash) error {
+	return w.Put(headBlockKey, h[:])
+}
+
+// ReadHeadHash returns the stored head hash, or storage.ErrNotFound.
+func ReadHeadHash(r storage.Reader) (Hash, error) {
+	data, err := r.Get(headBlockKey)
+	if err != nil {
+		return Hash{}, err
+	}
+	var h Hash
+	copy(h[:], data)
+	return h, nil
+}
+
+// WriteFinalizedHash stores the hash of the highest finalized block.
+func WriteFinalizedHash(w storage.Writer, h Hash) error {
+	return w.Put(finalizedKey, h[:])
+}
+
+// ReadFinalizedHash returns the finalized block hash, or storage.ErrNotFound.
+func ReadFinalizedHash(r storage.Reader) (Hash, error) {
+	data, err := r.Get(finalizedKey)
+	if err != nil {
+		return Hash{}, err
+	}
+	var h Hash
+	copy(h[:], data)
+	return h, nil
+}
+
+// WriteStateRoot stores the root of the last committed state.
+func WriteStateRoot(w storage.Writer, root Hash) error {
+	return w.Put(headStateKey, root[:])
+}
+
+// ReadStateRoot returns the last committed state root, or storage.ErrNotFound.
+func ReadStateRoot(r storage.Reader) (Hash, error) {
+	data, err := r.Get(headStateKey)
+	if err != nil {
+		return Hash{}, err
+	}
+	var h Hash
+	copy(h[:], data)
+	return h, nil
+}
+
+// WriteValidatorSet stores the active validator set of an epoch.
+func WriteValidatorSet(w storage.Writer, epoch uint64, vs *ValidatorSet) error {
+	vals := vs.Validators()
+	if vals == nil {
+		vals = []ValidatorStake{}
+	}
+	data, err := json.Marshal(vals)
+	if err != nil {
+		return err
+	}
+	return w.Put(valSetKey(epoch), data)
+}
+
+// ReadValidatorSet loads the active validator set of an epoch.
+func ReadValidatorSet(r storage.Reader, epoch uint64) (*ValidatorSet, error) {
+	data, err := r.Get(valSetKey(epoch))
+	if err != nil {
+		return nil, err
+	}
+	var vals []ValidatorStake
+	if err := json.Unmarshal(data, &vals); err != nil {
+		return nil, err
+	}
+	return NewValidatorSet(vals), nil
+}
+
+// DeleteValidatorSet removes the validator set of an epoch.
+func DeleteValidatorSet(w storage.Writer, epoch uint64) error {
+	return w.Delete(valSetKey(epoch))
+}
+
+// WriteIssuance stores the issuance record of a block.
+func WriteIssuance(w storage.Writer, h Hash, is *Issuance) error {
+	data, err := json.Marshal(is)
+	if err != nil {
+		return err
+	}
+	return w.Put(issuanceKey(h), data)
+}
+
+// ReadIssuance loads the issuance record of a block.
+func ReadIssuance(r storage.Reader, h Hash) (*Issuance, error) {
+	data, err := r.Get(issuanceKey(h))
+	if err != nil {
+		return nil, err
+	}
+	var is Issuance
+	if err := json.Unmarshal(data, &is); err != nil {
+		return nil, err
+	}
+	return &is, nil
+}
+
+// WriteReceipts stores the receipts of a block's transactions.
+func WriteReceipts(w storage.Writer, h Hash, receipts []*Receipt) error {
+	data, err := json.Marshal(receipts)
+	if err != nil {
+		return err
+	}
+	return w.Put(receiptsKey(h), data)
+}
+
+// ReadReceipts loads the receipts of a block's transactions.
+func ReadReceipts(r storage.Reader, h Hash) ([]*Receipt, error) {
+	data, err := r.Get(receiptsKey(h))
+	if err != nil {
+		return nil, err
+	}
+	var receipts []*Receipt
+	if err := json.Unmarshal(data, &receipts); err != nil {
+		return nil, err
+	}
+	return receipts, nil
+}
+
+// WriteTxLookup stores where a transaction was included.
+func WriteTxLookup(w storage.Writer, txHash Hash, l *TxLookup) error {
+	data, err := json.Marshal(l)
+	if err != nil {
+		return err
+	}
+	return w.Put(txLookupKey(txHash), data)
+}
+
+// ReadTxLookup loads where a transaction was included.
+func ReadTxLookup(r storage.Reader, txHash Hash) (*TxLookup, error) {
+	data, err := r.Get(txLookupKey(txHash))
+	if err != nil {
+		return nil, err
+	}
+	var l TxLookup
+	if err := json.Unmarshal(data, &l); err != nil {
+		return nil, err
+	}
+	return &l, nil
+}
@@ -6,6 +6,7 @@ package types
 import (
 	"encoding/json"
 	"errors"
+	"math/big"
 )
 
 // EncodeTx serializes a transaction to bytes (JSON-based for now).
@@ -16,6 +17,15 @@ func EncodeTx(tx *Transaction) ([]byte, error) {
 	return json.Marshal(tx)
 }
 
+// TxSize returns the encoded size of tx in bytes.
+func TxSize(tx *Transaction) int {
+	data, err := EncodeTx(tx)
+	if err != nil {
+		return 0
+	}
+	return len(data)
+}
+
 // DecodeTx deserializes a transaction from bytes.
 func DecodeTx(data []byte) (*Transaction, error) {
 	if len(data) == 0 {
@@ -46,4 +56,52 @@ func DecodeBlock(data []byte) (*Block, error) {
 		return nil, err
 	}
 	return &blk, nil
-}
\ No newline at end of file
+}
+// EncodeHeader serializes a block header to bytes.
+func EncodeHeader(h *BlockHeader) ([]byte, error) {
+	if h == nil {
+		return nil, errors.New("nil header")
+	}
+	return json.Marshal(h)
+}
+
+// DecodeHeader deserializes a block header from bytes.
+func DecodeHeader(data []byte) (*BlockHeader, error) {
+	if len(data) == 0 {
+		return nil, errors.New("empty header data")
+	}
+	var h BlockHeader
+	if err := json.Unmarshal(data, &h); err != nil {
+		return nil, err
+	}
+	return &h, nil
+}
+
+// EncodeAccount serializes an account for storage in the state trie.
+func EncodeAccount(acc *Account) ([]byte, error) {
+	if acc == nil {
+		return nil, errors.New("nil account")
+	}
+	return json.Marshal(acc)
+}
+
+// DecodeAccount deserializes an account from the state trie.
+func DecodeAccount(data []byte) (*Account, error) {
+	if len(data) == 0 {
+		return nil, errors.New("empty account data")
+	}
+	var acc Account
+	if err := json.Unmarshal(data, &acc); err != nil {
+		return nil, err
+	}
+	if acc.Balance == nil {
+		acc.Balance = big.NewInt(0)
+	}
+	if acc.Stake == nil {
+		acc.Stake = big.NewInt(0)
+	}
+	if acc.DelegatedStake == nil {
+		acc.DelegatedStake = big.NewInt(0)
+	}
+	return &acc, nil
+}
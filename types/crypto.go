@@ -84,6 +84,19 @@ func RecoverTxSender(tx *Transaction) (Address, error) {
 	if tx == nil {
 		return Address{}, errors.New("nil transaction")
 	}
+	// Multisig txs come from their account once the cosignatures recover;
+	// the threshold is checked against state by StateDB.CheckMultisig.
+	if tx.IsMultisig() {
+		if len(tx.Cosignatures) == 0 {
+			return Address{}, ErrMultisigThreshold
+		}
+		if _, err := recoverCosigners(tx); err != nil {
+			return Address{}, err
+		}
+		addr := *tx.Multisig
+		tx.from = &addr
+		return addr, nil
+	}
 	if tx.Signature.R == nil || tx.Signature.S == nil {
 		return Address{}, errors.New("missing signature components")
 	}
@@ -137,6 +150,63 @@ func VerifyTxSignature(tx *Transaction) (bool, error) {
 	return true, nil
 }
 
+// ErrHeaderSignature is returned for a header not signed by its Proposer.
+var ErrHeaderSignature = errors.New("proposer signature mismatch")
+
+// SignHeader signs the header hash with the proposer key and sets
+// h.Proposer to the signing address.
+func SignHeader(h *BlockHeader, priv *ecdsa.PrivateKey) error {
+	if h == nil {
+		return errors.New("nil header")
+	}
+	if priv == nil {
+		return errors.New("nil private key")
+	}
+
+	h.Proposer = PubKeyToAddress(&priv.PublicKey)
+	hash := h.HashHeader()
+
+	sig, err := gethcrypto.Sign(hash[:], priv)
+	if err != nil {
+		return err
+	}
+	if len(sig) != 65 {
+		return errors.New("invalid signature length")
+	}
+
+	h.Signature = Signature{
+		R: new(big.Int).SetBytes(sig[0:32]),
+		S: new(big.Int).SetBytes(sig[32:64]),
+		V: sig[64],
+	}
+	return nil
+}
+
+// VerifyHeaderSignature checks that the header is signed by its Proposer.
+func VerifyHeaderSignature(h *BlockHeader) error {
+	if h == nil {
+		return errors.New("nil header")
+	}
+	if h.Signature.R == nil || h.Signature.S == nil {
+		return errors.New("missing proposer signature")
+	}
+
+	sig := make([]byte, 65)
+	copy(sig[0:32], padTo32(h.Signature.R.Bytes()))
+	copy(sig[32:64], padTo32(h.Signature.S.Bytes()))
+	sig[64] = h.Signature.V
+
+	hash := h.HashHeader()
+	pubKey, err := gethcrypto.SigToPub(hash[:], sig)
+	if err != nil {
+		return err
+	}
+	if PubKeyToAddress(pubKey) != h.Proposer {
+		return ErrHeaderSignature
+	}
+	return nil
+}
+
 // padTo32 left-pads the given byte slice to 32 bytes.
 func padTo32(b []byte) []byte {
 	if len(b) >= 32 {
@@ -0,0 +1,121 @@
+// SPDX-License-Identifier: MIT
+// Dev: KryperAI
+
+package types
+
+import (
+	"krypper-chain/storage"
+)
+
+// The active Tier-2 validator set changes only at epoch boundaries. The set
+// for epoch e is selected from the stake in state after the last block of
+// epoch e-1 (after genesis for epoch 0): the top ChainConfig.ValidatorCount
+// stakers holding at least ChainConfig.MinStake.
+
+// ValidatorSetAt returns the active validator set for blocks at height.
+// It is empty if the epoch has not been reached yet.
+func (bc *Blockchain) ValidatorSetAt(height uint64) *ValidatorSet {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+	return bc.validatorSetAt(height)
+}
+
+// ValidatorSet returns the set that validates the next block.
+func (bc *Blockchain) ValidatorSet() *ValidatorSet {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+	if bc.head == nil {
+		return NewValidatorSet(nil)
+	}
+	return bc.validatorSetAt(bc.head.Header.Height + 1)
+}
+
+// validatorSetAt is ValidatorSetAt without locking. While a reorg runs,
+// sets activated by the new branch take precedence over the canonical ones.
+// Caller must hold bc.mu.
+func (bc *Blockchain) validatorSetAt(height uint64) *ValidatorSet {
+	cfg := bc.executor.Config()
+	epoch := cfg.Epoch(height)
+	if vs, ok := bc.stagedSets[epoch]; ok {
+		return vs
+	}
+	if vs, ok := bc.validatorSets[epoch]; ok {
+		return vs
+	}
+	vs, err := ReadValidatorSet(bc.db, epoch)
+	if err != nil {
+		return NewValidatorSet(nil)
+	}
+	return vs
+}
+
+// activeSetAt returns the validator set for height without the members
+// the current state has jailed, so a slashed validator stops proposing and
+// voting at once rather than at the next epoch's selection. Caller must
+// hold bc.mu; the state must be that of the parent of height or later.
+func (bc *Blockchain) activeSetAt(height uint64) *ValidatorSet {
+	vs := bc.validatorSetAt(height)
+	kept := make([]ValidatorStake, 0, vs.Len())
+	for _, v := range vs.Validators() {
+		if !bc.state.IsJailed(v.Address, height) {
+			kept = append(kept, v)
+		}
+	}
+	if len(kept) == vs.Len() {
+		return vs
+	}
+	return NewValidatorSet(kept)
+}
+
+// stageValidatorSet selects the next epoch's validator set from the current
+// state if b closes an epoch (or is genesis), writes it into the batch and
+// records it in sets. Caller must hold bc.mu and state must reflect b.
+func (bc *Blockchain) stageValidatorSet(w storage.Writer, b *Block, sets map[uint64]*ValidatorSet) error {
+	cfg := bc.executor.Config()
+	height := b.Header.Height
+
+	var epochs []uint64
+	if height == 0 {
+		epochs = append(epochs, 0)
+	}
+	if cfg.IsEpochEnd(height) {
+		epochs = append(epochs, cfg.Epoch(height+1))
+	}
+	if len(epochs) == 0 {
+		return nil
+	}
+
+	// Validators jailed past the start of the next epoch are left out.
+	stakers, err := bc.state.Stakers(height + 1)
+	if err != nil {
+		return err
+	}
+	vs := SelectValidatorSet(stakers, cfg.ValidatorCount, cfg.MinStake)
+	for _, epoch := range epochs {
+		if err := WriteValidatorSet(w, epoch, vs); err != nil {
+			return err
+		}
+		sets[epoch] = vs
+	}
+	return nil
+}
+
+// dropValidatorSets deletes the sets activated by canonical blocks above
+// height up to and including old, which a reorg abandons, and returns
+// their epochs for the caller to drop from the cache once w is written.
+// Caller must hold bc.mu.
+func (bc *Blockchain) dropValidatorSets(w storage.Writer, height, old uint64) ([]uint64, error) {
+	cfg := bc.executor.Config()
+	var epochs []uint64
+	for h := height + 1; h <= old; h++ {
+		if !cfg.IsEpochEnd(h) {
+			continue
+		}
+		epoch := cfg.Epoch(h + 1)
+		if err := DeleteValidatorSet(w, epoch); err != nil {
+			return nil, err
+		}
+		epochs = append(epochs, epoch)
+	}
+	return epochs, nil
+}
@@ -0,0 +1,135 @@
+// SPDX-License-Identifier: MIT
+// Dev: KryperAI
+
+package types
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestSelectValidatorSet(t *testing.T) {
+	addr := func(b byte) Address {
+		var a Address
+		a[0] = b
+		return a
+	}
+	stakers := []ValidatorStake{
+		{Address: addr(4), Stake: big.NewInt(50)},
+		{Address: addr(3), Stake: big.NewInt(30)},
+		{Address: addr(2), Stake: big.NewInt(30)},
+		{Address: addr(1), Stake: big.NewInt(100)},
+		{Address: addr(5), Stake: big.NewInt(5)},
+		// Delegations count toward weight but not toward min stake.
+		{Address: addr(6), Stake: big.NewInt(500), SelfStake: big.NewInt(1)},
+	}
+	vs := SelectValidatorSet(stakers, 3, big.NewInt(10))
+	if vs.Len() != 3 || !vs.Contains(addr(1)) || !vs.Contains(addr(4)) || !vs.Contains(addr(2)) {
+		t.Fatalf("selected %v", vs.Validators())
+	}
+	if vs.Contains(addr(3)) {
+		t.Fatal("tie not broken by lower address")
+	}
+	if vs.TotalStake().Int64() != 180 {
+		t.Fatalf("total stake %s", vs.TotalStake())
+	}
+	if SelectValidatorSet(stakers, 0, nil).Len() != len(stakers) {
+		t.Fatal("count 0 should not limit the set")
+	}
+}
+
+func TestValidatorSetChangesAtEpochBoundary(t *testing.T) {
+	key, rich := newTestKey(t)
+	_, v := newTestKey(t)
+	c := newTestChain(t, rich, ValidatorStake{Address: v, Stake: big.NewInt(10)})
+
+	// Stake added mid-epoch only shows up in the next epoch's set.
+	stake := NewStakingTx(1, TxTypeStake, 0, Address{}, big.NewInt(20), big.NewInt(1), 50000)
+	SignTransaction(stake, key)
+	c.mine(t, []*Transaction{stake}, Address{}, 1)
+	c.mine(t, nil, Address{}, 2)
+	c.mine(t, nil, Address{}, 3) // closes epoch 0 (heights 0-3)
+
+	for h := uint64(0); h < 4; h++ {
+		if vs := c.chain.ValidatorSetAt(h); vs.Len() != 1 || !vs.Contains(v) {
+			t.Fatalf("epoch 0 set at height %d changed", h)
+		}
+	}
+	next := c.chain.ValidatorSetAt(4)
+	if next.Len() != 2 || !next.Contains(rich) || next.StakeOf(rich).Int64() != 20 {
+		t.Fatal("epoch 1 set missing new staker")
+	}
+}
+
+func TestReorgAcrossEpochBoundaryWithDifferentStake(t *testing.T) {
+	key, rich := newTestKey(t)
+	_, v := newTestKey(t)
+	stake := ValidatorStake{Address: v, Stake: big.NewInt(10)}
+	a := newTestChain(t, rich, stake)
+	b := newTestChain(t, rich, stake)
+
+	// Branch a keeps v as the only validator into epoch 1.
+	for ts := int64(1); ts <= 4; ts++ {
+		a.mine(t, nil, Address{}, ts)
+	}
+
+	// Branch b stakes rich at height 1, so its epoch 1 set (heights 4-7)
+	// and proposer schedule differ, and grows longer.
+	tx := NewStakingTx(1, TxTypeStake, 0, Address{}, big.NewInt(1000), big.NewInt(1), 50000)
+	SignTransaction(tx, key)
+	var branch []*Block
+	branch = append(branch, b.mine(t, []*Transaction{tx}, Address{}, 11))
+	for ts := int64(12); ts <= 16; ts++ {
+		branch = append(branch, b.mine(t, nil, Address{}, ts))
+	}
+	differs := false
+	for h := uint64(4); h <= 6; h++ {
+		pa, _ := a.chain.ProposerAt(h, 0)
+		pb, _ := b.chain.ProposerAt(h, 0)
+		differs = differs || pa != pb
+	}
+	if !differs {
+		t.Skip("branches happen to schedule the same proposers")
+	}
+
+	for _, blk := range branch {
+		if err := a.chain.AddBlock(blk); err != nil {
+			t.Fatalf("height %d: %v", blk.Header.Height, err)
+		}
+	}
+	if a.chain.Head().Hash() != branch[len(branch)-1].Hash() {
+		t.Fatalf("head at %d, not on the heavier branch", a.chain.Head().Header.Height)
+	}
+	if vs := a.chain.ValidatorSetAt(4); !vs.Contains(rich) || vs.StakeOf(rich).Int64() != 1000 {
+		t.Fatal("epoch 1 set of the new branch not installed")
+	}
+}
+
+func TestReorgDropsAbandonedValidatorSets(t *testing.T) {
+	_, rich := newTestKey(t)
+	_, v := newTestKey(t)
+	stake := ValidatorStake{Address: v, Stake: big.NewInt(10)}
+	a := newTestChain(t, rich, stake)
+	b := newTestChain(t, rich, stake)
+
+	// Branch a closes epoch 0; branch b is shorter but attested, so it
+	// wins without reaching the boundary.
+	for ts := int64(1); ts <= 3; ts++ {
+		a.mine(t, nil, Address{}, ts)
+	}
+	b1 := b.mine(t, nil, v, 11)
+	b2 := b.mine(t, nil, v, 12)
+	if a.chain.ValidatorSetAt(4).Len() == 0 {
+		t.Fatal("epoch 1 set not activated")
+	}
+	a.chain.AddBlock(b1)
+	if err := a.chain.AddBlock(b2); err != nil {
+		t.Fatal(err)
+	}
+	if a.chain.Head().Hash() != b2.Hash() {
+		t.Fatal("no reorg to the attested branch")
+	}
+	if a.chain.ValidatorSetAt(4).Len() != 0 {
+		t.Fatal("epoch 1 set of the abandoned branch kept")
+	}
+}
@@ -5,6 +5,7 @@ package types
 
 import (
         "errors"
+        "fmt"
         "math/big"
 )
 
@@ -12,6 +13,8 @@ type Receipt struct {
         TxHash  Hash
         Success bool
         GasUsed uint64
+        Code    ReceiptCode // failure class; ReceiptOK on success
+        Error   string      // why execution failed; not hashed
         Logs    [][]byte
 }
 
@@ -26,21 +29,101 @@ type ChainConfig struct {
         SharePool  uint64 // Reserve/Fund
 
         // % total <= 100 → remainder = auto-burn
+
+        // Tier-2 validator set selection
+        ValidatorCount  int      // max active validators per epoch
+        MinStake        *big.Int // minimum self stake to be eligible
+        EpochLength     uint64   // blocks per epoch (0 → DefaultEpochLength)
+        UnbondingPeriod uint64   // blocks before unstaked funds are released (0 → DefaultUnbondingPeriod)
+
+        // Block limits (see headerverify.go)
+        MaxBlockSize uint64 // max encoded size of a block's txs in bytes (0 → unlimited)
+
+        // Fee market (see fees.go)
+        BaseFeeToPool bool // credit the base fee to RewardPool instead of burning it
+
+        // Block subsidy (see issuance.go)
+        BaseReward              *big.Int // reward of the first blocks
+        RewardReductionInterval uint64   // blocks between reward reductions (0 → constant reward)
+        RewardReductionPercent  uint64   // % the reward drops per interval (0 → DefaultRewardReductionPercent)
+
+        // Slashing (see slashing.go)
+        SlashPercent uint64 // % of stake slashed per offence (0 → DefaultSlashPercent)
+        JailPeriod   uint64 // blocks an offender is excluded from selection (0 → DefaultJailPeriod)
+
+        // Account freezing (see freeze.go)
+        FreezeAdmins     []Address // admin multisig members
+        FreezeThreshold  uint64    // admin approvals required (0 → admin freezing disabled)
+        GovernanceFreeze bool      // let a validator stake quorum freeze accounts
+}
+
+// DefaultEpochLength is used when ChainConfig.EpochLength is unset.
+const DefaultEpochLength uint64 = 100
+
+// Epoch returns the epoch a block height belongs to.
+func (c *ChainConfig) Epoch(height uint64) uint64 {
+        return height / c.epochLength()
+}
+
+// IsEpochEnd reports whether height is the last block of its epoch.
+func (c *ChainConfig) IsEpochEnd(height uint64) bool {
+        return (height+1)%c.epochLength() == 0
+}
+
+// UnbondingRelease returns the height at which stake unbonded at height
+// is returned to the balance.
+func (c *ChainConfig) UnbondingRelease(height uint64) uint64 {
+        period := c.UnbondingPeriod
+        if period == 0 {
+                period = DefaultUnbondingPeriod
+        }
+        return height + period
+}
+
+func (c *ChainConfig) epochLength() uint64 {
+        if c.EpochLength == 0 {
+                return DefaultEpochLength
+        }
+        return c.EpochLength
 }
 
 type Executor struct {
         state   *StateDB
         config  ChainConfig
         current *BlockHeader
+
+        // Gas, supply changes and receipts of the current block
+        // (see issuance.go, receipt.go)
+        gasUsed  uint64
+        reward   *big.Int
+        burned   *big.Int
+        receipts []*Receipt
+
+        // validators returns the active validator set at a height. It is
+        // set by NewBlockchain and nil for an executor without a chain.
+        validators func(height uint64) *ValidatorSet
 }
 
 func NewExecutor(state *StateDB, cfg ChainConfig) *Executor {
-        return &Executor{state: state, config: cfg}
+        return &Executor{state: state, config: cfg, reward: big.NewInt(0), burned: big.NewInt(0)}
 }
 
-func (e *Executor) SetBlock(h *BlockHeader) { e.current = h }
+// Config returns the chain configuration used for execution.
+func (e *Executor) Config() ChainConfig { return e.config }
+
+func (e *Executor) SetBlock(h *BlockHeader) { e.SetCurrentHeader(h) }
 
-func (e *Executor) SetCurrentHeader(h *BlockHeader) { e.current = h }
+// SetCurrentHeader starts executing the block with header h.
+func (e *Executor) SetCurrentHeader(h *BlockHeader) {
+        e.current = h
+        e.gasUsed = 0
+        e.reward = big.NewInt(0)
+        e.burned = big.NewInt(0)
+        e.receipts = nil
+}
+
+// GasUsed returns the gas used by the current block so far.
+func (e *Executor) GasUsed() uint64 { return e.gasUsed }
 
 func (e *Executor) SetCoinbase(addr Address) {
         if e.current != nil {
@@ -55,7 +138,7 @@ func (e *Executor) ExecuteBlock(b *Block) ([]*Receipt, error) {
                 return nil, errors.New("invalid block")
         }
 
-        e.current = b.Header
+        e.SetCurrentHeader(b.Header)
         receipts := make([]*Receipt, len(b.Transactions))
 
         for i, tx := range b.Transactions {
@@ -75,18 +158,51 @@ func (e *Executor) ExecuteTx(tx *Transaction) (*Receipt, error) {
         if tx == nil {
                 return nil, errors.New("nil tx")
         }
+        if err := tx.ValidateBasic(); err != nil {
+                return nil, err
+        }
+        if err := tx.CheckChainID(e.config.ChainID); err != nil {
+                return nil, err
+        }
 
         from, err := RecoverTxSender(tx)
         if err != nil {
-                return nil, errors.New("invalid signature")
+                return nil, fmt.Errorf("invalid signature: %w", err)
+        }
+        if err := e.state.CheckMultisig(tx); err != nil {
+                return nil, err
+        }
+        if err := tx.CheckNonce(e.state.GetNonce(from)); err != nil {
+                return nil, err
+        }
+        if e.state.IsFrozen(from) {
+                return nil, ErrAccountFrozen
         }
 
+        // The whole gas limit must fit in what is left of the block.
+        if tx.GasLimit > e.current.GasLimit-e.gasUsed {
+                return nil, ErrBlockGasLimit
+        }
+
+        baseFee := e.current.BaseFee
+        if baseFee == nil {
+                baseFee = big.NewInt(0)
+        }
+        tip, err := tx.EffectiveTip(baseFee)
+        if err != nil {
+                return nil, err
+        }
+        gasPrice := new(big.Int).Add(baseFee, tip)
+
         snap := e.state.Snapshot() // <- rollback layer
 
-        fee := new(big.Int).Mul(new(big.Int).SetUint64(tx.GasLimit), tx.GasPrice)
-        total := new(big.Int).Add(tx.Value, fee)
+        // Matured unbonding stake becomes spendable before anything else.
+        e.state.ReleaseUnbonded(from, e.current.Height)
+
+        // Buy the full gas limit up front; unused gas is refunded below.
+        prepaid := new(big.Int).Mul(new(big.Int).SetUint64(tx.GasLimit), gasPrice)
 
-        if err := e.state.SubBalance(from, total); err != nil {
+        if err := e.state.SubBalance(from, prepaid); err != nil {
                 e.state.RevertToSnapshot(snap)
                 return nil, err
         }
@@ -94,45 +210,85 @@ func (e *Executor) ExecuteTx(tx *Transaction) (*Receipt, error) {
                 e.state.RevertToSnapshot(snap)
                 return nil, err
         }
-        if tx.Value.Sign() > 0 {
-                if err := e.state.AddBalance(tx.To, tx.Value); err != nil {
-                        e.state.RevertToSnapshot(snap)
-                        return nil, err
-                }
+
+        // A failing state change is undone, but the tx stays in the block:
+        // its nonce is used and its gas charged.
+        receipt := &Receipt{TxHash: tx.Hash(), Success: true}
+        execSnap := e.state.Snapshot()
+        if err := e.applyTx(from, tx); err != nil {
+                e.state.RevertToSnapshot(execSnap)
+                receipt.Success = false
+                receipt.Code = receiptCodeOf(err)
+                receipt.Error = err.Error()
+        } else {
+                e.state.CommitSnapshot(execSnap)
+        }
+
+        gasUsed := IntrinsicGas(tx)
+        used := new(big.Int).SetUint64(gasUsed)
+        if refund := new(big.Int).Sub(prepaid, new(big.Int).Mul(used, gasPrice)); refund.Sign() > 0 {
+                e.state.AddBalance(from, refund)
         }
 
         // ---------------------------------------------------------
-        // 🔥 Tier reward distribution
+        // 🔥 Base fee is burned or pooled; tips go to the tiers and
+        // the undistributed rest of them is burned
         // ---------------------------------------------------------
-        t1 := calcPct(fee, e.config.ShareTier1)
-        t2 := calcPct(fee, e.config.ShareTier2)
-        t3 := calcPct(fee, e.config.ShareTier3)
-        pfund := calcPct(fee, e.config.SharePool)
-
-        if t1.Sign() > 0 && !e.current.Proposer.IsZero() {
-                e.state.AddBalance(e.current.Proposer, t1)
-        }
-        if t2.Sign() > 0 && !e.current.Validator.IsZero() {
-                e.state.AddBalance(e.current.Validator, t2)
-        }
-        if t3.Sign() > 0 && !e.current.Witness.IsZero() {
-                e.state.AddBalance(e.current.Witness, t3)
-        }
-        if pfund.Sign() > 0 {
-                e.state.AddBalance(e.config.RewardPool, pfund)
+        baseFeePaid := new(big.Int).Mul(used, baseFee)
+        tips := new(big.Int).Mul(used, tip)
+        paid := e.distribute(tips)
+        burned := new(big.Int).Sub(tips, paid)
+        if e.config.BaseFeeToPool {
+                if baseFeePaid.Sign() > 0 {
+                        e.state.AddBalance(e.config.RewardPool, baseFeePaid)
+                }
+        } else {
+                burned.Add(burned, baseFeePaid)
         }
 
         // ---------------------------------------------------------
         // 🧹 Important fix → clear snapshot (prevent RAM leak)
         // ---------------------------------------------------------
         e.state.CommitSnapshot(snap)
+        e.burned.Add(e.burned, burned)
+        e.gasUsed += gasUsed
+
+        receipt.GasUsed = gasUsed
+        e.receipts = append(e.receipts, receipt)
+        return receipt, nil
+}
 
-        return &Receipt{
-                TxHash:  tx.Hash(),
-                Success: true,
-                GasUsed: tx.GasLimit,
-                Logs:    nil,
-        }, nil
+// applyTx performs the type-specific state change of a transaction.
+func (e *Executor) applyTx(from Address, tx *Transaction) error {
+        switch tx.Type {
+        case TxTypeTransfer:
+                if tx.Value.Sign() == 0 {
+                        return nil
+                }
+                if err := e.state.SubBalance(from, tx.Value); err != nil {
+                        return err
+                }
+                return e.state.AddBalance(tx.To, tx.Value)
+        case TxTypeStake:
+                return e.state.AddStake(from, tx.Value)
+        case TxTypeUnstake:
+                return e.state.Unstake(from, tx.Value, e.config.UnbondingRelease(e.current.Height))
+        case TxTypeDelegate:
+                return e.state.Delegate(from, tx.To, tx.Value)
+        case TxTypeUndelegate:
+                return e.state.Undelegate(from, tx.To, tx.Value, e.config.UnbondingRelease(e.current.Height))
+        case TxTypeEvidence:
+                return e.applyEvidence(tx.Data)
+        case TxTypeFreeze:
+                return e.applyFreeze(tx.Data)
+        case TxTypeBatch:
+                return e.applyBatch(from, tx)
+        case TxTypeMultisigCreate:
+                return e.applyMultisigCreate(from, tx)
+        case TxTypeMultisigUpdate:
+                return e.applyMultisigUpdate(from, tx)
+        }
+        return errors.New("unsupported tx type")
 }
 
 func calcPct(base *big.Int, pct uint64) *big.Int {
@@ -0,0 +1,143 @@
+// SPDX-License-Identifier: MIT
+// Dev: KryperAI
+
+package types
+
+import (
+	"errors"
+	"math/big"
+	"sort"
+)
+
+// Fee market. Each header carries a BaseFee that moves towards keeping
+// blocks half full; a transaction pays the base fee plus a priority tip,
+// capped by its max fee. The base fee is burned (or routed to the reward
+// pool) and the tip is split across the tiers like a block reward.
+//
+// Legacy transactions without MaxFeePerGas pay GasPrice, i.e. their max fee
+// and tip are both GasPrice.
+
+const (
+	BaseFeeChangeDenominator = 8 // base fee moves by at most 1/8 per block
+	ElasticityMultiplier     = 2 // gas target is GasLimit / 2
+)
+
+// InitialBaseFee is the base fee of genesis (1 gwei).
+var InitialBaseFee = big.NewInt(1_000_000_000)
+
+// DefaultPriorityFee is the suggested tip when recent blocks carry no txs.
+var DefaultPriorityFee = big.NewInt(1_000_000_000)
+
+var ErrFeeCapTooLow = errors.New("max fee per gas below block base fee")
+
+// CalcBaseFee returns the base fee of the child of parent.
+func CalcBaseFee(parent *BlockHeader) *big.Int {
+	if parent.BaseFee == nil {
+		return new(big.Int).Set(InitialBaseFee)
+	}
+	target := parent.GasLimit / ElasticityMultiplier
+	if target == 0 || parent.GasUsed == target {
+		return new(big.Int).Set(parent.BaseFee)
+	}
+
+	var diff uint64
+	if parent.GasUsed > target {
+		diff = parent.GasUsed - target
+	} else {
+		diff = target - parent.GasUsed
+	}
+	delta := new(big.Int).Mul(parent.BaseFee, new(big.Int).SetUint64(diff))
+	delta.Div(delta, new(big.Int).SetUint64(target))
+	delta.Div(delta, big.NewInt(BaseFeeChangeDenominator))
+
+	if parent.GasUsed > target {
+		if delta.Sign() == 0 {
+			delta.SetInt64(1)
+		}
+		return delta.Add(delta, parent.BaseFee)
+	}
+	out := new(big.Int).Sub(parent.BaseFee, delta)
+	if out.Sign() < 0 {
+		out.SetInt64(0)
+	}
+	return out
+}
+
+// SetDynamicFee turns tx into a fee-market transaction paying at most
+// maxFee per gas, of which at most tip goes to the tiers. It must be
+// called before signing.
+func (tx *Transaction) SetDynamicFee(maxFee, tip *big.Int) {
+	tx.MaxFeePerGas = new(big.Int).Set(maxFee)
+	tx.MaxPriorityFeePerGas = new(big.Int).Set(tip)
+	tx.GasPrice = big.NewInt(0)
+	tx.hash = Hash{}
+}
+
+// IsDynamicFee reports whether tx uses max-fee/tip pricing.
+func (tx *Transaction) IsDynamicFee() bool {
+	return tx.MaxFeePerGas != nil
+}
+
+// FeeCap returns the most tx pays per gas.
+func (tx *Transaction) FeeCap() *big.Int {
+	if tx.IsDynamicFee() {
+		return tx.MaxFeePerGas
+	}
+	return tx.GasPrice
+}
+
+// TipCap returns the most tx tips per gas.
+func (tx *Transaction) TipCap() *big.Int {
+	if tx.IsDynamicFee() {
+		return tx.MaxPriorityFeePerGas
+	}
+	return tx.GasPrice
+}
+
+// EffectiveTip returns the tip per gas tx pays at baseFee, or an error if
+// its fee cap does not cover the base fee.
+func (tx *Transaction) EffectiveTip(baseFee *big.Int) (*big.Int, error) {
+	if baseFee == nil {
+		baseFee = big.NewInt(0)
+	}
+	if tx.FeeCap().Cmp(baseFee) < 0 {
+		return nil, ErrFeeCapTooLow
+	}
+	tip := new(big.Int).Sub(tx.FeeCap(), baseFee)
+	if tip.Cmp(tx.TipCap()) > 0 {
+		tip.Set(tx.TipCap())
+	}
+	return tip, nil
+}
+
+// SuggestFeeCap returns a max fee per gas paying tip on top of baseFee
+// that stays valid while the base fee doubles.
+func SuggestFeeCap(baseFee, tip *big.Int) *big.Int {
+	feeCap := new(big.Int).Mul(baseFee, big.NewInt(2))
+	return feeCap.Add(feeCap, tip)
+}
+
+// SuggestTip returns the median tip paid per gas over the last blocks
+// canonical blocks, or DefaultPriorityFee if they carry no transactions.
+func (bc *Blockchain) SuggestTip(blocks int) *big.Int {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	var tips []*big.Int
+	for b := bc.head; b != nil && blocks > 0; blocks-- {
+		for _, tx := range b.Transactions {
+			if tip, err := tx.EffectiveTip(b.Header.BaseFee); err == nil {
+				tips = append(tips, tip)
+			}
+		}
+		if b.Header.Height == 0 {
+			break
+		}
+		b = bc.blockByHash(b.Header.ParentHash)
+	}
+	if len(tips) == 0 {
+		return new(big.Int).Set(DefaultPriorityFee)
+	}
+	sort.Slice(tips, func(i, j int) bool { return tips[i].Cmp(tips[j]) < 0 })
+	return new(big.Int).Set(tips[len(tips)/2])
+}
@@ -0,0 +1,106 @@
+// SPDX-License-Identifier: MIT
+// Dev: KryperAI
+
+package types
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestCalcBaseFee(t *testing.T) {
+	cases := []struct {
+		used uint64
+		want int64
+	}{
+		{1000, 900}, // full block: +1/8
+		{0, 700},    // empty block: -1/8
+		{500, 800},  // at target: unchanged
+	}
+	for _, c := range cases {
+		p := &BlockHeader{GasLimit: 1000, GasUsed: c.used, BaseFee: big.NewInt(800)}
+		if got := CalcBaseFee(p); got.Int64() != c.want {
+			t.Errorf("gas used %d: base fee %s, want %d", c.used, got, c.want)
+		}
+	}
+}
+
+func TestEffectiveTip(t *testing.T) {
+	var to Address
+	tx := NewTransferTx(1, 0, to, big.NewInt(1), nil, TxGas, nil)
+	tx.SetDynamicFee(big.NewInt(150), big.NewInt(20))
+	if tip, err := tx.EffectiveTip(big.NewInt(100)); err != nil || tip.Int64() != 20 {
+		t.Fatalf("tip %v, %v", tip, err)
+	}
+	// The fee cap limits the tip once the base fee leaves less room.
+	if tip, err := tx.EffectiveTip(big.NewInt(140)); err != nil || tip.Int64() != 10 {
+		t.Fatalf("capped tip %v, %v", tip, err)
+	}
+	if _, err := tx.EffectiveTip(big.NewInt(151)); err != ErrFeeCapTooLow {
+		t.Fatalf("fee cap below base fee: %v", err)
+	}
+	if c := SuggestFeeCap(big.NewInt(100), big.NewInt(20)); c.Int64() != 220 {
+		t.Fatalf("suggested fee cap %s", c)
+	}
+}
+
+func TestBaseFeeBurnedAndTipShared(t *testing.T) {
+	key, rich := newTestKey(t)
+	c := newTestChain(t, rich)
+	c.exec.config.ShareTier1, c.exec.config.SharePool = 60, 10
+	c.exec.config.RewardPool[0] = 0xAA
+
+	var proposer Address
+	proposer[0] = 3
+	c.exec.SetCurrentHeader(&BlockHeader{Height: 1, GasLimit: 1e6, BaseFee: big.NewInt(100), Proposer: proposer})
+
+	tx := NewTransferTx(1, 0, Address{5}, big.NewInt(1), nil, TxGas, nil)
+	tx.SetDynamicFee(big.NewInt(150), big.NewInt(20))
+	SignTransaction(tx, key)
+	before := c.state.GetBalance(rich)
+	if _, err := c.exec.ExecuteTx(tx); err != nil {
+		t.Fatal(err)
+	}
+	gas := int64(TxGas)
+	if spent := new(big.Int).Sub(before, c.state.GetBalance(rich)); spent.Int64() != 1+gas*120 {
+		t.Fatalf("spent %s, want value + gas * (base fee + tip)", spent)
+	}
+	if got := c.state.GetBalance(proposer).Int64(); got != gas*20*60/100 {
+		t.Fatalf("proposer got %d", got)
+	}
+	// The base fee and the unassigned 30% of the tip are burned.
+	if _, burned := c.exec.BlockIssuance(); burned.Int64() != gas*100+gas*20*30/100 {
+		t.Fatalf("burned %s", burned)
+	}
+
+	low := NewTransferTx(1, 1, Address{5}, big.NewInt(1), nil, TxGas, nil)
+	low.SetDynamicFee(big.NewInt(99), big.NewInt(20))
+	SignTransaction(low, key)
+	if _, err := c.exec.ExecuteTx(low); err != ErrFeeCapTooLow {
+		t.Fatalf("fee cap below base fee executed: %v", err)
+	}
+}
+
+func TestMempoolRejectsFeeCapBelowBaseFee(t *testing.T) {
+	key, rich := newTestKey(t)
+	c := newTestChain(t, rich)
+	c.mine(t, nil, Address{}, 1)
+	if c.pool.baseFee == nil || c.pool.baseFee.Cmp(CalcBaseFee(c.chain.Head().Header)) != 0 {
+		t.Fatal("mempool not given the next base fee")
+	}
+
+	c.pool.SetBaseFee(big.NewInt(100))
+	var to Address
+	low := NewTransferTx(1, 0, to, big.NewInt(1), nil, TxGas, nil)
+	low.SetDynamicFee(big.NewInt(99), big.NewInt(1))
+	SignTransaction(low, key)
+	if err := c.pool.AddTx(low); err != ErrFeeCapTooLow {
+		t.Fatalf("low fee cap: %v", err)
+	}
+	ok := NewTransferTx(1, 0, to, big.NewInt(1), nil, TxGas, nil)
+	ok.SetDynamicFee(big.NewInt(100), big.NewInt(1))
+	SignTransaction(ok, key)
+	if err := c.pool.AddTx(ok); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,111 @@
+// SPDX-License-Identifier: MIT
+// Dev: KryperAI
+
+package types
+
+import (
+	"errors"
+	"math/big"
+)
+
+// ErrReorgBelowFinalized is returned when a branch would revert a
+// finalized block.
+var ErrReorgBelowFinalized = errors.New("reorg would revert finalized block")
+
+// ErrNotActiveValidator is returned for votes from addresses outside the
+// validator set active at the voted height.
+var ErrNotActiveValidator = errors.New("voter is not in the active validator set")
+
+// FinalizedHead returns the highest finalized block. Genesis is final.
+func (bc *Blockchain) FinalizedHead() *Block {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+	return bc.finalized
+}
+
+// AddVote records a Tier-2 vote and finalizes the voted block once votes
+// from more than 2/3 of the stake of the validator set active at its height
+// have been collected. Finalizing a side-chain block switches the canonical
+// chain to it. It reports whether the vote finalized a block.
+func (bc *Blockchain) AddVote(v *ValidatorVote) (bool, error) {
+	voter, err := VerifyValidatorVote(v)
+	if err != nil {
+		return false, err
+	}
+	if id := bc.executor.config.ChainID; id != 0 && v.ChainID != id {
+		return false, errors.New("vote for wrong chain")
+	}
+
+	var (
+		orphaned  []*Transaction
+		finalized bool
+	)
+	err = func() error {
+		bc.mu.Lock()
+		defer bc.mu.Unlock()
+
+		b := bc.blockByHash(v.Block)
+		if b == nil {
+			return errors.New("vote for unknown block")
+		}
+		if b.Header.Height != v.Height {
+			return errors.New("vote height mismatch")
+		}
+		if bc.finalized != nil && v.Height <= bc.finalized.Header.Height {
+			return nil
+		}
+		vs := bc.activeSetAt(v.Height)
+		if !vs.Contains(voter) {
+			return ErrNotActiveValidator
+		}
+
+		set, ok := bc.votes[v.Block]
+		if !ok {
+			set = make(map[Address]struct{})
+			bc.votes[v.Block] = set
+		}
+		set[voter] = struct{}{}
+
+		voted := big.NewInt(0)
+		for addr := range set {
+			voted.Add(voted, vs.StakeOf(addr))
+		}
+		if !vs.HasQuorum(voted) {
+			return nil
+		}
+
+		if !bc.isCanonical(b) {
+			if orphaned, err = bc.reorg(b); err != nil {
+				return err
+			}
+		}
+		if err := bc.finalize(b); err != nil {
+			return err
+		}
+		finalized = true
+		return nil
+	}()
+	if err != nil {
+		return false, err
+	}
+
+	bc.returnToMempool(orphaned)
+	return finalized, nil
+}
+
+// finalize marks b (and thereby its ancestors) final and drops votes that
+// can no longer matter. Caller must hold bc.mu (write lock).
+func (bc *Blockchain) finalize(b *Block) error {
+	if err := WriteFinalizedHash(bc.db, b.Hash()); err != nil {
+		return err
+	}
+	bc.finalized = b
+
+	for h := range bc.votes {
+		voted := bc.blockByHash(h)
+		if voted == nil || voted.Header.Height <= b.Header.Height {
+			delete(bc.votes, h)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+// SPDX-License-Identifier: MIT
+// Dev: KryperAI
+
+package types
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+)
+
+func TestFinalityQuorum(t *testing.T) {
+	_, rich := newTestKey(t)
+	keys := make([]*ecdsa.PrivateKey, 4)
+	var stakes []ValidatorStake
+	for i := range keys {
+		k, addr := newTestKey(t)
+		keys[i] = k
+		stakes = append(stakes, ValidatorStake{Address: addr, Stake: big.NewInt(10)})
+	}
+	c := newTestChain(t, rich, stakes...)
+
+	// Three slots for four equal stakers: one key is left out.
+	vs := c.chain.ValidatorSet()
+	if vs.Len() != 3 {
+		t.Fatalf("set size %d", vs.Len())
+	}
+	var active []*ecdsa.PrivateKey
+	var outsider *ecdsa.PrivateKey
+	for _, k := range keys {
+		if vs.Contains(PrivateKeyToAddress(k)) {
+			active = append(active, k)
+		} else {
+			outsider = k
+		}
+	}
+
+	b1 := c.mine(t, nil, Address{}, 1)
+	c.mine(t, nil, Address{}, 2)
+
+	v, _ := SignValidatorVote(outsider, 1, 1, b1.Hash())
+	if _, err := c.chain.AddVote(v); err != ErrNotActiveValidator {
+		t.Fatalf("outsider vote: %v", err)
+	}
+	// 2 of 3 equal stakes is not more than 2/3; the third vote finalizes.
+	for i, k := range active {
+		v, _ := SignValidatorVote(k, 1, 1, b1.Hash())
+		fin, err := c.chain.AddVote(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fin != (i == 2) {
+			t.Fatalf("vote %d finalized=%v", i, fin)
+		}
+	}
+	if c.chain.FinalizedHead().Hash() != b1.Hash() {
+		t.Fatal("block not finalized")
+	}
+
+	wrongChain, _ := SignValidatorVote(active[0], 2, 2, c.chain.Head().Hash())
+	if _, err := c.chain.AddVote(wrongChain); err == nil {
+		t.Fatal("vote for another chain accepted")
+	}
+}
+
+func TestNoForkBelowFinalized(t *testing.T) {
+	_, rich := newTestKey(t)
+	k, v := newTestKey(t)
+	c := newTestChain(t, rich, ValidatorStake{Address: v, Stake: big.NewInt(10)})
+	b1 := c.mine(t, nil, Address{}, 1)
+	vote, _ := SignValidatorVote(k, 1, 1, b1.Hash())
+	if fin, err := c.chain.AddVote(vote); err != nil || !fin {
+		t.Fatalf("sole validator vote: %v %v", fin, err)
+	}
+
+	genesis := c.chain.GetBlockByHeight(0)
+	side := &BlockHeader{
+		ChainID:    1,
+		ParentHash: genesis.Hash(),
+		Height:     1,
+		Timestamp:  testGenesisTime + 9,
+		GasLimit:   genesis.Header.GasLimit,
+		StateRoot:  genesis.Header.StateRoot,
+		BaseFee:    CalcBaseFee(genesis.Header),
+	}
+	SignHeader(side, k)
+	if err := c.chain.AddBlock(NewBlock(side, nil)); err == nil || err.Error() != "block below finalized height" {
+		t.Fatalf("fork below finalized block: %v", err)
+	}
+}
+
+func TestHasQuorum(t *testing.T) {
+	var a, b Address
+	a[0], b[0] = 1, 2
+	vs := NewValidatorSet([]ValidatorStake{{Address: a, Stake: big.NewInt(2)}, {Address: b, Stake: big.NewInt(1)}})
+	if vs.HasQuorum(big.NewInt(2)) {
+		t.Fatal("exactly 2/3 is not a quorum")
+	}
+	if !vs.HasQuorum(big.NewInt(3)) {
+		t.Fatal("full stake is a quorum")
+	}
+}
@@ -0,0 +1,212 @@
+// SPDX-License-Identifier: MIT
+// Dev: KryperAI
+
+package types
+
+import (
+	"bytes"
+	"errors"
+)
+
+// blockWeight is the fork-choice weight a single block adds to its branch.
+// Every block counts 1; a block carrying a Tier-2 validator attestation
+// whose vote signature verifies counts 2, so the most-attested branch wins
+// over a merely longer one. A block attested by a validator outside the
+// active set is invalid: it is refused before it is stored if its epoch's
+// set is known (see verifySideBlock), and otherwise dropped when a reorg
+// executes it.
+func blockWeight(h *BlockHeader) uint64 {
+	w := uint64(1)
+	if !h.Validator.IsZero() && verifyHeaderValidator(h) == nil {
+		w++
+	}
+	return w
+}
+
+// verifySideBlock runs the signature, proposer slot and validator
+// membership checks of applyBlock on a block that does not extend the
+// head, before it is stored and weighed. The slot and membership need the
+// validator set of the block's epoch, which is known here only if the
+// block closing the epoch before is canonical; otherwise they run when a
+// reorg executes the branch. Caller must hold bc.mu.
+func (bc *Blockchain) verifySideBlock(h, parent *BlockHeader) error {
+	if err := verifyHeaderValidator(h); err != nil {
+		return err
+	}
+	cfg := bc.executor.Config()
+	if epoch := cfg.Epoch(h.Height); epoch > 0 {
+		b := bc.ancestorAt(h.ParentHash, epoch*cfg.epochLength()-1)
+		if b == nil || !bc.isCanonical(b) {
+			return nil
+		}
+	}
+	if err := bc.verifyProposer(h, parent); err != nil {
+		return err
+	}
+	if !h.Validator.IsZero() && !bc.activeSetAt(h.Height-1).Contains(h.Validator) {
+		return ErrIneligibleTier
+	}
+	return nil
+}
+
+// heavier reports whether branch a beats branch b. Ties go to the lower
+// block hash so every node picks the same head.
+func heavier(aWeight uint64, aHash Hash, bWeight uint64, bHash Hash) bool {
+	if aWeight != bWeight {
+		return aWeight > bWeight
+	}
+	return bytes.Compare(aHash[:], bHash[:]) < 0
+}
+
+// reorg switches the canonical chain to the branch ending in newHead.
+//
+// It rewinds state to the common ancestor, re-executes the new branch block
+// by block, and on success rewrites the canonical index and head pointer in
+// one batch. It returns the transactions of the abandoned branch that are
+// not included in the new one. On failure the old head and state are kept.
+// Branches forking below the finalized block are refused.
+// Caller must hold bc.mu (write lock).
+func (bc *Blockchain) reorg(newHead *Block) ([]*Transaction, error) {
+	oldHead := bc.head
+
+	// Walk the new branch back to the first canonical ancestor.
+	var newBranch []*Block
+	ancestor := newHead
+	for !bc.isCanonical(ancestor) {
+		newBranch = append(newBranch, ancestor)
+		parent := bc.blockByHash(ancestor.Header.ParentHash)
+		if parent == nil {
+			return nil, errors.New("reorg: missing ancestor")
+		}
+		ancestor = parent
+	}
+	if bc.finalized != nil && ancestor.Header.Height < bc.finalized.Header.Height {
+		return nil, ErrReorgBelowFinalized
+	}
+
+	// Canonical blocks above the ancestor are abandoned.
+	var oldBranch []*Block
+	for h := oldHead.Header.Height; h > ancestor.Header.Height; h-- {
+		b := bc.canonicalAt(h)
+		if b == nil {
+			return nil, errors.New("reorg: missing canonical block")
+		}
+		oldBranch = append(oldBranch, b)
+	}
+
+	if err := bc.state.Reset(ancestor.Header.StateRoot); err != nil {
+		return nil, err
+	}
+
+	restore := func(cause error) ([]*Transaction, error) {
+		if err := bc.state.Reset(oldHead.Header.StateRoot); err != nil {
+			return nil, err
+		}
+		bc.executor.SetCurrentHeader(oldHead.Header)
+		return nil, cause
+	}
+
+	// Re-apply the winning branch oldest first, committing each state so
+	// its root stays available for proofs and later reorgs. Validator sets
+	// the branch activates apply to its later blocks before they are
+	// installed.
+	batch := bc.db.NewBatch()
+	sets := make(map[uint64]*ValidatorSet)
+	bc.stagedSets = sets
+	defer func() { bc.stagedSets = nil }()
+	for i := len(newBranch) - 1; i >= 0; i-- {
+		b := newBranch[i]
+		if err := bc.applyBlock(b); err != nil {
+			bc.dropSideBranch(newBranch[:i+1])
+			return restore(err)
+		}
+		weight, err := bc.weightOf(b.Hash())
+		if err != nil {
+			return restore(err)
+		}
+		if err := bc.writeCanonicalBlock(batch, b, weight, sets); err != nil {
+			return restore(err)
+		}
+	}
+	for h := oldHead.Header.Height; h > newHead.Header.Height; h-- {
+		if err := DeleteCanonicalHash(batch, h); err != nil {
+			return restore(err)
+		}
+	}
+	dropped, err := bc.dropValidatorSets(batch, newHead.Header.Height, oldHead.Header.Height)
+	if err != nil {
+		return restore(err)
+	}
+	if err := batch.Write(); err != nil {
+		return restore(err)
+	}
+	bc.state.MarkStored()
+
+	// Swap the in-memory canonical index.
+	for h := oldHead.Header.Height; h > ancestor.Header.Height; h-- {
+		delete(bc.blocksByHeight, h)
+	}
+	for _, b := range newBranch {
+		bc.blocksByHeight[b.Header.Height] = b
+	}
+	for _, epoch := range dropped {
+		delete(bc.validatorSets, epoch)
+	}
+	for epoch, vs := range sets {
+		bc.validatorSets[epoch] = vs
+	}
+	bc.head = newHead
+
+	// Collect abandoned transactions the new branch did not include.
+	included := make(map[Hash]struct{})
+	for _, b := range newBranch {
+		for _, tx := range b.Transactions {
+			included[tx.Hash()] = struct{}{}
+		}
+	}
+	var orphaned []*Transaction
+	for i := len(oldBranch) - 1; i >= 0; i-- {
+		for _, tx := range oldBranch[i].Transactions {
+			if _, ok := included[tx.Hash()]; !ok {
+				orphaned = append(orphaned, tx)
+			}
+		}
+	}
+	return orphaned, nil
+}
+
+// isCanonical reports whether b is the canonical block at its height.
+// Caller must hold bc.mu.
+func (bc *Blockchain) isCanonical(b *Block) bool {
+	c := bc.canonicalAt(b.Header.Height)
+	return c != nil && c.Hash() == b.Hash()
+}
+
+// canonicalAt returns the canonical block at height, or nil.
+// Caller must hold bc.mu.
+func (bc *Blockchain) canonicalAt(height uint64) *Block {
+	if b, ok := bc.blocksByHeight[height]; ok {
+		return b
+	}
+	h, err := ReadCanonicalHash(bc.db, height)
+	if err != nil {
+		return nil
+	}
+	return bc.blockByHash(h)
+}
+
+// dropSideBranch forgets side blocks that failed execution so they are not
+// chosen again. Their descendants become unreachable with them.
+// Caller must hold bc.mu (write lock).
+func (bc *Blockchain) dropSideBranch(blocks []*Block) {
+	batch := bc.db.NewBatch()
+	for _, b := range blocks {
+		h := b.Hash()
+		delete(bc.blocksByHash, h)
+		delete(bc.weights, h)
+		_ = batch.Delete(headerKey(h))
+		_ = batch.Delete(bodyKey(h))
+		_ = batch.Delete(weightKey(h))
+	}
+	_ = batch.Write()
+}
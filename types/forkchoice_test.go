@@ -0,0 +1,137 @@
+// SPDX-License-Identifier: MIT
+// Dev: KryperAI
+
+package types
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestReorgToHeavierBranch(t *testing.T) {
+	key, rich := newTestKey(t)
+	_, v := newTestKey(t)
+	stake := ValidatorStake{Address: v, Stake: big.NewInt(1000)}
+	a := newTestChain(t, rich, stake)
+	b := newTestChain(t, rich, stake)
+	if a.chain.Head().Hash() != b.chain.Head().Hash() {
+		t.Fatal("genesis differs")
+	}
+
+	var to1, to2 Address
+	to1[0], to2[0] = 1, 2
+	a.mine(t, []*Transaction{signedTransfer(t, key, 0, to1, 5, 1)}, Address{}, 1)
+	a.mine(t, nil, Address{}, 2)
+
+	// The competing branch spends the same nonce and, at height 2, carries
+	// a Tier-2 validator's signed vote for its parent, which makes it
+	// heavier.
+	b1 := b.mine(t, []*Transaction{signedTransfer(t, key, 0, to2, 7, 1)}, Address{}, 3)
+	b2 := b.mine(t, nil, v, 4)
+
+	if err := a.chain.AddBlock(b1); err != nil {
+		t.Fatal(err)
+	}
+	if a.chain.Head().Header.Height != 2 || a.state.GetBalance(to1).Int64() != 5 {
+		t.Fatal("reorged to a lighter branch")
+	}
+
+	if err := a.chain.AddBlock(b2); err != nil {
+		t.Fatal(err)
+	}
+	if a.chain.Head().Hash() != b2.Hash() {
+		t.Fatal("no reorg to heavier branch")
+	}
+	if a.state.GetBalance(to1).Sign() != 0 || a.state.GetBalance(to2).Int64() != 7 {
+		t.Fatal("state not rewound")
+	}
+	if a.state.StateRoot() != b2.Header.StateRoot {
+		t.Fatal("state root differs from new head")
+	}
+	if a.chain.GetBlockByHeight(1).Hash() != b1.Hash() {
+		t.Fatal("canonical index not updated")
+	}
+	// The orphaned transfer reuses nonce 0 and is dropped.
+	if a.pool.Count() != 0 {
+		t.Fatalf("mempool holds %d txs", a.pool.Count())
+	}
+}
+
+func TestSideBlockNeedsKnownParent(t *testing.T) {
+	_, rich := newTestKey(t)
+	c := newTestChain(t, rich)
+	h, key := c.nextHeader(Address{}, 1)
+	h.ParentHash = Hash{1}
+	SignHeader(h, key)
+	if err := c.chain.AddBlock(NewBlock(h, nil)); err == nil {
+		t.Fatal("block with unknown parent accepted")
+	}
+	head := c.chain.Head()
+	if err := c.chain.AddBlock(head); err == nil {
+		t.Fatal("known block accepted twice")
+	}
+}
+
+func TestUnsignedAttestationAddsNoWeight(t *testing.T) {
+	_, rich := newTestKey(t)
+	vk, v := newTestKey(t)
+	c := newTestChain(t, rich, ValidatorStake{Address: v, Stake: big.NewInt(1000)})
+
+	h, _ := c.nextHeader(v, 1)
+	if blockWeight(h) != 2 {
+		t.Fatal("signed attestation not counted")
+	}
+
+	// Naming a validator without its vote, or with a vote for another
+	// block, does not make a branch heavier.
+	h.ValidatorSig = nil
+	if blockWeight(h) != 1 {
+		t.Fatal("unsigned attestation counted")
+	}
+	vote, _ := SignValidatorVote(vk, 1, h.Height-1, Hash{9})
+	h.ValidatorSig = vote.SigBytes()
+	if blockWeight(h) != 1 {
+		t.Fatal("attestation of another block counted")
+	}
+}
+
+// Side blocks whose epoch set is known are checked against it before they
+// are stored, so outsiders cannot build up weight on a side branch.
+func TestSideBlockChecksKnownSet(t *testing.T) {
+	_, rich := newTestKey(t)
+	_, v := newTestKey(t)
+	outsider, _ := newTestKey(t)
+	stake := ValidatorStake{Address: v, Stake: big.NewInt(1000)}
+	a := newTestChain(t, rich, stake)
+	b := newTestChain(t, rich, stake)
+	for ts := int64(1); ts <= 3; ts++ {
+		a.mine(t, nil, Address{}, ts)
+	}
+
+	// A key outside the set signing its own attestation.
+	attested := b.sealAt(t, nil, 2, func(h *BlockHeader) { attest(h, outsider) })
+	if err := a.chain.AddBlock(attested); err != ErrIneligibleTier {
+		t.Fatalf("outsider attestation: %v", err)
+	}
+	// A proposer that does not hold the slot.
+	unscheduled := b.sealAt(t, nil, 2, nil)
+	unscheduled.Header.Proposer = PrivateKeyToAddress(testDefaultKey)
+	resign(t, unscheduled.Header)
+	if err := a.chain.AddBlock(unscheduled); err != ErrWrongProposer {
+		t.Fatalf("unscheduled proposer: %v", err)
+	}
+	for _, blk := range []*Block{attested, unscheduled} {
+		if a.chain.GetBlockByHash(blk.Hash()) != nil {
+			t.Fatal("rejected side block stored")
+		}
+	}
+
+	// A valid side block is still stored.
+	side := b.sealAt(t, nil, 2, nil)
+	if err := a.chain.AddBlock(side); err != nil {
+		t.Fatal(err)
+	}
+	if a.chain.GetBlockByHash(side.Hash()) == nil {
+		t.Fatal("side block not stored")
+	}
+}
@@ -0,0 +1,250 @@
+// SPDX-License-Identifier: MIT
+// Dev: KryperAI
+
+package types
+
+import (
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"math/big"
+
+	gethcrypto "github.com/ethereum/go-ethereum/crypto"
+)
+
+// A frozen account cannot send transactions; it can still receive funds.
+// Accounts are frozen and unfrozen by a TxTypeFreeze transaction carrying
+// a FreezeAction. Anyone may submit it, but it only takes effect with
+// approvals from either
+//
+//   - FreezeThreshold of ChainConfig.FreezeAdmins (admin multisig), or
+//   - validators holding a 2/3 quorum of the stake of the block's active
+//     validator set, if ChainConfig.GovernanceFreeze is set (governance).
+//
+// Every change is appended to the target's freeze history, whose length
+// is the sequence approvals are signed over, so they cannot be replayed.
+
+// FreezeAuthority records which path authorized a freeze change.
+type FreezeAuthority uint8
+
+const (
+	FreezeByAdmin      FreezeAuthority = 0x01
+	FreezeByGovernance FreezeAuthority = 0x02
+)
+
+var (
+	ErrAccountFrozen      = errors.New("sender account is frozen")
+	ErrInvalidFreeze      = errors.New("invalid freeze action")
+	ErrFreezeSequence     = errors.New("freeze action sequence mismatch")
+	ErrFreezeUnauthorized = errors.New("freeze action lacks required approvals")
+	ErrFreezeNoop         = errors.New("account already in requested freeze state")
+)
+
+// FreezeAction asks to freeze or unfreeze Target. It is the Data of a
+// TxTypeFreeze transaction.
+type FreezeAction struct {
+	Target    Address  `json:"target"`
+	Freeze    bool     `json:"freeze"`
+	Reason    string   `json:"reason,omitempty"`
+	Sequence  uint64   `json:"sequence"`  // length of Target's freeze history
+	Approvals [][]byte `json:"approvals"` // 65-byte signatures over hashForSign
+}
+
+// FreezeRecord is one entry of an account's freeze history.
+type FreezeRecord struct {
+	Height    uint64          `json:"height"`
+	Frozen    bool            `json:"frozen"`
+	Reason    string          `json:"reason,omitempty"`
+	Authority FreezeAuthority `json:"authority"`
+	Approvers []Address       `json:"approvers"`
+}
+
+// EncodeFreezeAction returns the canonical encoding used in tx data.
+func EncodeFreezeAction(a *FreezeAction) ([]byte, error) {
+	return json.Marshal(a)
+}
+
+// DecodeFreezeAction decodes a freeze action from tx data.
+func DecodeFreezeAction(data []byte) (*FreezeAction, error) {
+	var a FreezeAction
+	if err := json.Unmarshal(data, &a); err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// hashForSign binds approvals to the chain, the change and the sequence.
+func (a *FreezeAction) hashForSign(chainID uint64) Hash {
+	h := sha256.New()
+	var buf [8]byte
+
+	h.Write([]byte("krypper-freeze"))
+	binary.BigEndian.PutUint64(buf[:], chainID)
+	h.Write(buf[:])
+	h.Write(a.Target[:])
+	if a.Freeze {
+		h.Write([]byte{1})
+	} else {
+		h.Write([]byte{0})
+	}
+	binary.BigEndian.PutUint64(buf[:], a.Sequence)
+	h.Write(buf[:])
+	h.Write([]byte(a.Reason))
+
+	var out Hash
+	copy(out[:], h.Sum(nil))
+	return out
+}
+
+// Approve adds priv's approval of a on chainID.
+func (a *FreezeAction) Approve(priv *ecdsa.PrivateKey, chainID uint64) error {
+	if priv == nil {
+		return errors.New("nil private key")
+	}
+	digest := a.hashForSign(chainID)
+	sig, err := gethcrypto.Sign(digest[:], priv)
+	if err != nil {
+		return err
+	}
+	a.Approvals = append(a.Approvals, sig)
+	return nil
+}
+
+// Approvers returns the distinct addresses that approved a on chainID.
+func (a *FreezeAction) Approvers(chainID uint64) ([]Address, error) {
+	digest := a.hashForSign(chainID)
+	seen := make(map[Address]bool, len(a.Approvals))
+	out := make([]Address, 0, len(a.Approvals))
+	for _, sig := range a.Approvals {
+		if len(sig) != 65 {
+			return nil, errors.New("approval signature must be 65 bytes")
+		}
+		pub, err := gethcrypto.SigToPub(digest[:], sig)
+		if err != nil {
+			return nil, err
+		}
+		addr := PubKeyToAddress(pub)
+		if !seen[addr] {
+			seen[addr] = true
+			out = append(out, addr)
+		}
+	}
+	return out, nil
+}
+
+// NewFreezeTx builds an unsigned transaction submitting a.
+func NewFreezeTx(chainId uint64, nonce uint64, a *FreezeAction, gasPrice *big.Int, gasLimit uint64) (*Transaction, error) {
+	data, err := EncodeFreezeAction(a)
+	if err != nil {
+		return nil, err
+	}
+	tx := NewTransferTx(chainId, nonce, Address{}, nil, gasPrice, gasLimit, data)
+	tx.Type = TxTypeFreeze
+	return tx, nil
+}
+
+// IsFrozen reports whether addr may not send transactions.
+func (s *StateDB) IsFrozen(addr Address) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	acc := s.getAccount(addr)
+	return acc != nil && acc.Frozen
+}
+
+// FreezeHistory returns the freeze changes of addr, oldest first.
+func (s *StateDB) FreezeHistory(addr Address) []FreezeRecord {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	acc := s.getAccount(addr)
+	if acc == nil {
+		return nil
+	}
+	return acc.Copy().FreezeLog
+}
+
+// setFrozen applies rec to addr and appends it to the history.
+func (s *StateDB) setFrozen(addr Address, rec FreezeRecord) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	acc := s.getOrCreate(addr)
+	acc.Frozen = rec.Frozen
+	acc.FreezeLog = append(acc.FreezeLog, rec)
+}
+
+// applyFreeze verifies the freeze action carried in tx data and applies it.
+func (e *Executor) applyFreeze(data []byte) error {
+	a, err := DecodeFreezeAction(data)
+	if err != nil || a.Target.IsZero() {
+		return ErrInvalidFreeze
+	}
+
+	e.state.mu.Lock()
+	acc := e.state.getAccount(a.Target)
+	var seq uint64
+	frozen := false
+	if acc != nil {
+		seq, frozen = uint64(len(acc.FreezeLog)), acc.Frozen
+	}
+	e.state.mu.Unlock()
+
+	if a.Sequence != seq {
+		return ErrFreezeSequence
+	}
+	if a.Freeze == frozen {
+		return ErrFreezeNoop
+	}
+
+	approvers, err := a.Approvers(e.config.ChainID)
+	if err != nil {
+		return err
+	}
+	authority, err := e.freezeAuthority(approvers)
+	if err != nil {
+		return err
+	}
+
+	e.state.setFrozen(a.Target, FreezeRecord{
+		Height:    e.current.Height,
+		Frozen:    a.Freeze,
+		Reason:    a.Reason,
+		Authority: authority,
+		Approvers: approvers,
+	})
+	return nil
+}
+
+// freezeAuthority returns the path under which approvers may change a
+// freeze state, preferring the admin multisig.
+func (e *Executor) freezeAuthority(approvers []Address) (FreezeAuthority, error) {
+	if e.config.FreezeThreshold > 0 {
+		admins := make(map[Address]bool, len(e.config.FreezeAdmins))
+		for _, a := range e.config.FreezeAdmins {
+			admins[a] = true
+		}
+		var n uint64
+		for _, a := range approvers {
+			if admins[a] {
+				n++
+			}
+		}
+		if n >= e.config.FreezeThreshold {
+			return FreezeByAdmin, nil
+		}
+	}
+
+	// The set that validates the block, as fixed at the epoch start and
+	// without jailed members, not one selected from the current stake.
+	if e.config.GovernanceFreeze && e.validators != nil {
+		vs := e.validators(e.current.Height)
+		stake := big.NewInt(0)
+		for _, a := range approvers {
+			stake.Add(stake, vs.StakeOf(a))
+		}
+		if vs.HasQuorum(stake) {
+			return FreezeByGovernance, nil
+		}
+	}
+	return 0, ErrFreezeUnauthorized
+}
@@ -0,0 +1,141 @@
+// SPDX-License-Identifier: MIT
+// Dev: KryperAI
+
+package types
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+)
+
+// freezeChain is a test chain with three freeze admins, two of which must
+// approve, and a funded target account.
+type freezeChain struct {
+	*testChain
+	key       *ecdsa.PrivateKey // submits freeze txs
+	admins    []*ecdsa.PrivateKey
+	target    Address
+	targetKey *ecdsa.PrivateKey
+	nonce     uint64
+}
+
+func newFreezeChain(t *testing.T, stakes ...ValidatorStake) *freezeChain {
+	t.Helper()
+	key, rich := newTestKey(t)
+	c := newTestChain(t, rich, stakes...)
+	fc := &freezeChain{testChain: c, key: key}
+	for i := 0; i < 3; i++ {
+		k, a := newTestKey(t)
+		fc.admins = append(fc.admins, k)
+		c.exec.config.FreezeAdmins = append(c.exec.config.FreezeAdmins, a)
+	}
+	c.exec.config.FreezeThreshold = 2
+	fc.targetKey, fc.target = newTestKey(t)
+	c.state.Mint(fc.target, big.NewInt(1e18))
+	return fc
+}
+
+// submit mines a block holding a freeze tx for a and returns its receipt.
+func (fc *freezeChain) submit(t *testing.T, a *FreezeAction, validator Address, ts int64) *Receipt {
+	t.Helper()
+	tx, err := NewFreezeTx(1, fc.nonce, a, big.NewInt(1), 100_000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := SignTransaction(tx, fc.key); err != nil {
+		t.Fatal(err)
+	}
+	fc.nonce++
+	fc.mine(t, []*Transaction{tx}, validator, ts)
+	r, _, err := fc.chain.GetReceipt(tx.Hash())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func TestFreezeNeedsThreshold(t *testing.T) {
+	fc := newFreezeChain(t)
+	act := &FreezeAction{Target: fc.target, Freeze: true, Reason: "court order"}
+	act.Approve(fc.admins[0], 1)
+	if r := fc.submit(t, act, Address{}, 1); r.Success || r.Code != ReceiptFreezeUnauthorized {
+		t.Fatalf("frozen with one approval: %+v", r)
+	}
+
+	// A repeated approval does not count twice.
+	act.Approve(fc.admins[1], 1)
+	act.Approve(fc.admins[1], 1)
+	if r := fc.submit(t, act, Address{}, 2); !r.Success || !fc.state.IsFrozen(fc.target) {
+		t.Fatalf("not frozen: %+v", r)
+	}
+	h := fc.state.FreezeHistory(fc.target)
+	if len(h) != 1 || h[0].Authority != FreezeByAdmin || len(h[0].Approvers) != 2 {
+		t.Fatalf("history: %+v", h)
+	}
+}
+
+func TestFrozenAccountCannotSend(t *testing.T) {
+	fc := newFreezeChain(t)
+	act := &FreezeAction{Target: fc.target, Freeze: true}
+	act.Approve(fc.admins[0], 1)
+	act.Approve(fc.admins[2], 1)
+	fc.submit(t, act, Address{}, 1)
+
+	tx := signedTransfer(t, fc.targetKey, 0, Address{1}, 1, 1)
+	if err := fc.pool.AddTx(tx); err != ErrAccountFrozen {
+		t.Fatalf("mempool: %v", err)
+	}
+	h, _ := fc.nextHeader(Address{}, 2)
+	fc.exec.SetCurrentHeader(h)
+	if _, err := fc.exec.ExecuteTx(tx); err != ErrAccountFrozen {
+		t.Fatalf("executor: %v", err)
+	}
+}
+
+func TestGovernanceUnfreezeAndReplay(t *testing.T) {
+	kv, v := newTestKey(t)
+	fc := newFreezeChain(t, ValidatorStake{Address: v, Stake: big.NewInt(1000)})
+	act := &FreezeAction{Target: fc.target, Freeze: true}
+	act.Approve(fc.admins[0], 1)
+	act.Approve(fc.admins[1], 1)
+	fc.submit(t, act, Address{}, 1)
+
+	// The only validator holds all stake and may unfreeze on its own.
+	fc.exec.config.GovernanceFreeze = true
+	un := &FreezeAction{Target: fc.target, Freeze: false, Sequence: 1}
+	un.Approve(kv, 1)
+	if r := fc.submit(t, un, v, 2); !r.Success || fc.state.IsFrozen(fc.target) {
+		t.Fatalf("not unfrozen: %+v", r)
+	}
+	if h := fc.state.FreezeHistory(fc.target); h[1].Authority != FreezeByGovernance {
+		t.Fatalf("authority %v", h[1].Authority)
+	}
+
+	// The old freeze action cannot be replayed.
+	if r := fc.submit(t, act, Address{}, 3); r.Code != ReceiptFreezeSequence || fc.state.IsFrozen(fc.target) {
+		t.Fatalf("replayed: %+v", r)
+	}
+}
+
+func TestGovernanceQuorumFromActiveSet(t *testing.T) {
+	_, v := newTestKey(t)
+	fc := newFreezeChain(t, ValidatorStake{Address: v, Stake: big.NewInt(1000)})
+	fc.exec.config.GovernanceFreeze = true
+
+	// A newcomer outweighing the set is not a validator before the next
+	// epoch and cannot freeze on its own.
+	nk, n := newTestKey(t)
+	fc.state.Mint(n, big.NewInt(1e18))
+	stake := NewStakingTx(1, TxTypeStake, 0, Address{}, big.NewInt(100_000), big.NewInt(1), 50000)
+	if err := SignTransaction(stake, nk); err != nil {
+		t.Fatal(err)
+	}
+	fc.mine(t, []*Transaction{stake}, Address{}, 1)
+
+	act := &FreezeAction{Target: fc.target, Freeze: true}
+	act.Approve(nk, 1)
+	if r := fc.submit(t, act, Address{}, 2); r.Code != ReceiptFreezeUnauthorized || fc.state.IsFrozen(fc.target) {
+		t.Fatalf("frozen by a non-validator: %+v", r)
+	}
+}
@@ -0,0 +1,53 @@
+// SPDX-License-Identifier: MIT
+// Dev: KryperAI
+
+package types
+
+import "errors"
+
+// Intrinsic gas schedule. There is no VM yet, so a transaction's gas use
+// is its intrinsic gas: a base cost, a per-byte data cost and a surcharge
+// for types that do more state work than a transfer.
+const (
+	TxGas               uint64 = 21000 // base cost of every transaction
+	TxDataZeroGas       uint64 = 4     // per zero byte of data
+	TxDataNonZeroGas    uint64 = 16    // per non-zero byte of data
+	TxStakingGas        uint64 = 20000 // stake, unstake, delegate, undelegate
+	TxEvidenceGas       uint64 = 50000 // verifying two signatures of evidence
+	TxFreezeGas         uint64 = 25000 // recovering freeze approvals
+	TxBatchRecipientGas uint64 = 9000  // each recipient of a batch transfer
+	TxMultisigGas       uint64 = 20000 // creating or rotating a multisig account
+	TxCosignatureGas    uint64 = 3000  // each cosignature of a multisig tx
+)
+
+var (
+	ErrIntrinsicGas  = errors.New("gas limit below intrinsic gas")
+	ErrBlockGasLimit = errors.New("block gas limit exceeded")
+	ErrGasUsed       = errors.New("block gas used mismatch")
+)
+
+// IntrinsicGas returns the gas a transaction uses before any execution.
+func IntrinsicGas(tx *Transaction) uint64 {
+	gas := TxGas
+	for _, b := range tx.Data {
+		if b == 0 {
+			gas += TxDataZeroGas
+		} else {
+			gas += TxDataNonZeroGas
+		}
+	}
+	switch tx.Type {
+	case TxTypeStake, TxTypeUnstake, TxTypeDelegate, TxTypeUndelegate:
+		gas += TxStakingGas
+	case TxTypeEvidence:
+		gas += TxEvidenceGas
+	case TxTypeFreeze:
+		gas += TxFreezeGas
+	case TxTypeBatch:
+		gas += uint64(batchLen(tx)) * TxBatchRecipientGas
+	case TxTypeMultisigCreate, TxTypeMultisigUpdate:
+		gas += TxMultisigGas
+	}
+	gas += uint64(len(tx.Cosignatures)) * TxCosignatureGas
+	return gas
+}
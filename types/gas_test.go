@@ -0,0 +1,72 @@
+// SPDX-License-Identifier: MIT
+// Dev: KryperAI
+
+package types
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestIntrinsicGas(t *testing.T) {
+	var to Address
+	transfer := NewTransferTx(1, 0, to, big.NewInt(1), big.NewInt(1), 0, nil)
+	withData := NewTransferTx(1, 0, to, big.NewInt(1), big.NewInt(1), 0, []byte{0, 1, 2})
+	stake := NewStakingTx(1, TxTypeStake, 0, Address{}, big.NewInt(1), big.NewInt(1), 0)
+
+	cases := []struct {
+		name string
+		tx   *Transaction
+		want uint64
+	}{
+		{"transfer", transfer, TxGas},
+		{"data", withData, TxGas + TxDataZeroGas + 2*TxDataNonZeroGas},
+		{"staking", stake, TxGas + TxStakingGas},
+	}
+	for _, c := range cases {
+		if got := IntrinsicGas(c.tx); got != c.want {
+			t.Errorf("%s: %d, want %d", c.name, got, c.want)
+		}
+	}
+}
+
+func TestGasChargedAndRefunded(t *testing.T) {
+	key, rich := newTestKey(t)
+	c := newTestChain(t, rich)
+	var to Address
+	to[0] = 7
+	before := c.state.GetBalance(rich)
+
+	// The limit is well above use; only the gas used is paid.
+	tx := NewTransferTx(1, 0, to, big.NewInt(5), big.NewInt(2), 100000, []byte{0, 1})
+	SignTransaction(tx, key)
+	b := c.mine(t, []*Transaction{tx}, Address{}, 1)
+
+	used := TxGas + TxDataZeroGas + TxDataNonZeroGas
+	spent := new(big.Int).Sub(before, c.state.GetBalance(rich))
+	if spent.Int64() != 5+2*int64(used) || b.Header.GasUsed != used {
+		t.Fatalf("spent %s, gas used %d", spent, b.Header.GasUsed)
+	}
+}
+
+func TestGasLimits(t *testing.T) {
+	key, rich := newTestKey(t)
+	c := newTestChain(t, rich)
+	var to Address
+
+	low := NewTransferTx(1, 0, to, big.NewInt(1), big.NewInt(1), TxGas-1, nil)
+	SignTransaction(low, key)
+	if err := low.ValidateBasic(); err != ErrIntrinsicGas {
+		t.Fatalf("below intrinsic gas: %v", err)
+	}
+
+	c.exec.SetCurrentHeader(&BlockHeader{Height: 1, GasLimit: 40000})
+	first := signedTransfer(t, key, 0, to, 1, 1)
+	if _, err := c.exec.ExecuteTx(first); err != nil {
+		t.Fatal(err)
+	}
+	second := signedTransfer(t, key, 1, to, 1, 1)
+	if _, err := c.exec.ExecuteTx(second); err != ErrBlockGasLimit {
+		t.Fatalf("over block gas limit: %v", err)
+	}
+}
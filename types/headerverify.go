@@ -0,0 +1,138 @@
+// SPDX-License-Identifier: MIT
+// Dev: KryperAI
+
+package types
+
+import (
+	"errors"
+	"math"
+	"time"
+)
+
+// Header validation happens in three steps:
+//
+//   - Block.ValidateBasic checks what a block proves about itself: the tx
+//     root, gas used against the gas limit, absolute gas limit bounds and
+//     the basic validity of every transaction.
+//   - Blockchain.verifyHeader checks a block against its parent before it
+//     is stored: the chain ID, timestamps, gas limit adjustment, block size
+//     and the proposer signature.
+//   - applyBlock checks what depends on the parent state when the block is
+//     executed: the proposer slot, the base fee and the tiers (an active
+//     Tier-2 validator that voted for the parent, and the Tier-3 witness
+//     selected among the witnesses committed in the epoch before last).
+
+const (
+	MinGasLimit           uint64 = 5000          // lowest allowed block gas limit
+	MaxGasLimit           uint64 = math.MaxInt64 // highest allowed block gas limit
+	GasLimitBoundDivisor  uint64 = 1024          // limit moves < parent/divisor per block
+	MaxFutureBlockSeconds int64  = 15            // allowed clock drift of block timestamps
+)
+
+var (
+	ErrTxRoot         = errors.New("tx root mismatch")
+	ErrOldTimestamp   = errors.New("timestamp not after parent")
+	ErrFutureBlock    = errors.New("timestamp too far in the future")
+	ErrGasLimit       = errors.New("invalid gas limit")
+	ErrBlockSize      = errors.New("block size exceeds limit")
+	ErrIneligibleTier = errors.New("tier address not eligible")
+)
+
+// Size returns the encoded size of the block's transactions in bytes.
+func (b *Block) Size() int {
+	size := 0
+	for _, tx := range b.Transactions {
+		size += TxSize(tx)
+	}
+	return size
+}
+
+// CalcGasLimit returns the gas limit of a child of a block with gas limit
+// parent, moved as far toward target as the adjustment bound allows.
+func CalcGasLimit(parent, target uint64) uint64 {
+	if target == 0 {
+		return parent
+	}
+	if target < MinGasLimit {
+		target = MinGasLimit
+	}
+	delta := parent/GasLimitBoundDivisor - 1
+	switch {
+	case parent < target:
+		if target-parent < delta {
+			return target
+		}
+		return parent + delta
+	case parent > target:
+		if parent-target < delta {
+			return target
+		}
+		return parent - delta
+	}
+	return parent
+}
+
+// verifyGasLimit checks that limit is in bounds and, if parent is known,
+// differs from it by less than parent/GasLimitBoundDivisor.
+func verifyGasLimit(limit uint64, parent *BlockHeader) error {
+	if limit < MinGasLimit || limit > MaxGasLimit {
+		return ErrGasLimit
+	}
+	if parent == nil {
+		return nil
+	}
+	diff := limit - parent.GasLimit
+	if limit < parent.GasLimit {
+		diff = parent.GasLimit - limit
+	}
+	if diff >= parent.GasLimit/GasLimitBoundDivisor {
+		return ErrGasLimit
+	}
+	return nil
+}
+
+// verifyHeader checks b against its parent: the chain ID, a later
+// timestamp that is not too far in the future, the gas limit adjustment,
+// the block size and the proposer signature. Caller must hold bc.mu.
+func (bc *Blockchain) verifyHeader(b *Block, parent *Block) error {
+	h := b.Header
+	if id := bc.executor.config.ChainID; id != 0 && h.ChainID != id {
+		return ErrWrongChain
+	}
+	if h.Timestamp <= parent.Header.Timestamp {
+		return ErrOldTimestamp
+	}
+	if h.Timestamp > time.Now().Unix()+MaxFutureBlockSeconds {
+		return ErrFutureBlock
+	}
+	if err := verifyGasLimit(h.GasLimit, parent.Header); err != nil {
+		return err
+	}
+	if max := bc.executor.config.MaxBlockSize; max > 0 && uint64(b.Size()) > max {
+		return ErrBlockSize
+	}
+	return VerifyHeaderSignature(h)
+}
+
+// verifyTiers checks that the Tier-2 validator was in the unjailed set
+// that voted on the parent and signed its vote for it, and that the Tier-3
+// witness is the one selected among the witnesses committed on-chain in
+// the epoch before last (see witnessDraw). Either may be empty. Caller
+// must hold bc.mu and have the parent state loaded.
+func (bc *Blockchain) verifyTiers(h *BlockHeader) error {
+	if !h.Validator.IsZero() && !bc.activeSetAt(h.Height-1).Contains(h.Validator) {
+		return ErrIneligibleTier
+	}
+	if err := verifyHeaderValidator(h); err != nil {
+		return err
+	}
+	if err := verifyHeaderWitness(h); err != nil {
+		return err
+	}
+	if !h.Witness.IsZero() {
+		if want, ok := bc.selectedWitness(h.ParentHash, h.Height); !ok || h.Witness != want {
+			return ErrWrongWitness
+		}
+	}
+	return nil
+}
@@ -0,0 +1,293 @@
+// SPDX-License-Identifier: MIT
+// Dev: KryperAI
+
+package types
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+	"time"
+)
+
+// seal builds a valid block on top of the head like mine, but returns it
+// instead of adding it. mod runs before execution so that changes to the
+// tiers are reflected in the state root.
+func (c *testChain) seal(t *testing.T, txs []*Transaction, mod func(h *BlockHeader)) *Block {
+	t.Helper()
+	return c.sealAt(t, txs, 1, mod)
+}
+
+// sealAt is seal for a block ts seconds after genesis.
+func (c *testChain) sealAt(t *testing.T, txs []*Transaction, ts int64, mod func(h *BlockHeader)) *Block {
+	t.Helper()
+	h, key := c.nextHeader(Address{}, ts)
+	if mod != nil {
+		mod(h)
+	}
+
+	snap := c.state.Snapshot()
+	c.exec.SetCurrentHeader(h)
+	for _, tx := range txs {
+		if _, err := c.exec.ExecuteTx(tx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	c.exec.ApplyBlockReward()
+	h.GasUsed = c.exec.GasUsed()
+	h.ReceiptRoot = ReceiptRoot(c.exec.BlockReceipts())
+	h.StateRoot = c.state.StateRoot()
+	c.state.RevertToSnapshot(snap)
+
+	b := NewBlock(h, txs)
+	b.ComputeTxRoot()
+	if err := SignHeader(h, key); err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+// resign signs h again after a change made after sealing.
+func resign(t *testing.T, h *BlockHeader) {
+	t.Helper()
+	if err := SignHeader(h, testDefaultKey); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// twoWitnesses returns witnesses of the parent of h signed by k1 and k2, in
+// address order.
+func twoWitnesses(h *BlockHeader, k1, k2 *ecdsa.PrivateKey) []Witness {
+	w1, _ := SignWitness(k1, h.Height-1, h.ParentHash)
+	w2, _ := SignWitness(k2, h.Height-1, h.ParentHash)
+	if bytes.Compare(w2.Address[:], w1.Address[:]) < 0 {
+		w1, w2 = w2, w1
+	}
+	return []Witness{*w1, *w2}
+}
+
+func TestHeaderRules(t *testing.T) {
+	key, rich := newTestKey(t)
+	vk, v := newTestKey(t)
+	wk, _, _ := GenerateKey()
+	wk2, _, _ := GenerateKey()
+	stranger := Address{0x42}
+
+	cases := []struct {
+		name  string
+		rule  string
+		stake bool // stake v so it is an active validator
+		build func(t *testing.T, c *testChain) *Block
+		want  error
+	}{
+		{name: "valid", rule: "tx root", build: func(t *testing.T, c *testChain) *Block {
+			return c.seal(t, []*Transaction{signedTransfer(t, key, 0, Address{1}, 1, 1)}, nil)
+		}},
+		{name: "tampered", rule: "tx root", want: ErrTxRoot, build: func(t *testing.T, c *testChain) *Block {
+			b := c.seal(t, []*Transaction{signedTransfer(t, key, 0, Address{1}, 1, 1)}, nil)
+			b.Header.TxRoot[0] ^= 1
+			resign(t, b.Header)
+			return b
+		}},
+		{name: "after parent", rule: "timestamp", build: func(t *testing.T, c *testChain) *Block {
+			return c.seal(t, nil, func(h *BlockHeader) { h.Timestamp = testGenesisTime + 1 })
+		}},
+		{name: "same as parent", rule: "timestamp", want: ErrOldTimestamp, build: func(t *testing.T, c *testChain) *Block {
+			return c.seal(t, nil, func(h *BlockHeader) { h.Timestamp = testGenesisTime })
+		}},
+		{name: "within drift", rule: "timestamp", build: func(t *testing.T, c *testChain) *Block {
+			return c.seal(t, nil, func(h *BlockHeader) { h.Timestamp = time.Now().Unix() + MaxFutureBlockSeconds - 1 })
+		}},
+		{name: "too far ahead", rule: "timestamp", want: ErrFutureBlock, build: func(t *testing.T, c *testChain) *Block {
+			return c.seal(t, nil, func(h *BlockHeader) { h.Timestamp = time.Now().Unix() + MaxFutureBlockSeconds + 60 })
+		}},
+		{name: "largest step", rule: "gas limit", build: func(t *testing.T, c *testChain) *Block {
+			return c.seal(t, nil, func(h *BlockHeader) { h.GasLimit += h.GasLimit/GasLimitBoundDivisor - 1 })
+		}},
+		{name: "step too large", rule: "gas limit", want: ErrGasLimit, build: func(t *testing.T, c *testChain) *Block {
+			return c.seal(t, nil, func(h *BlockHeader) { h.GasLimit += h.GasLimit / GasLimitBoundDivisor })
+		}},
+		{name: "below minimum", rule: "gas limit", want: ErrGasLimit, build: func(t *testing.T, c *testChain) *Block {
+			return c.seal(t, nil, func(h *BlockHeader) { h.GasLimit = MinGasLimit - 1 })
+		}},
+		{name: "gas used above limit", rule: "gas limit", want: ErrBlockGasLimit, build: func(t *testing.T, c *testChain) *Block {
+			b := c.seal(t, nil, nil)
+			b.Header.GasUsed = b.Header.GasLimit + 1
+			resign(t, b.Header)
+			return b
+		}},
+		{name: "at limit", rule: "block size", build: func(t *testing.T, c *testChain) *Block {
+			tx := signedTransfer(t, key, 0, Address{1}, 1, 1)
+			c.exec.config.MaxBlockSize = uint64(TxSize(tx))
+			return c.seal(t, []*Transaction{tx}, nil)
+		}},
+		{name: "over limit", rule: "block size", want: ErrBlockSize, build: func(t *testing.T, c *testChain) *Block {
+			tx0 := signedTransfer(t, key, 0, Address{1}, 1, 1)
+			tx1 := signedTransfer(t, key, 1, Address{1}, 1, 1)
+			c.exec.config.MaxBlockSize = uint64(TxSize(tx0))
+			return c.seal(t, []*Transaction{tx0, tx1}, nil)
+		}},
+		{name: "active validator", rule: "tiers", stake: true, build: func(t *testing.T, c *testChain) *Block {
+			return c.seal(t, nil, func(h *BlockHeader) { attest(h, vk) })
+		}},
+		{name: "unknown validator", rule: "tiers", stake: true, want: ErrIneligibleTier, build: func(t *testing.T, c *testChain) *Block {
+			return c.seal(t, nil, func(h *BlockHeader) { h.Validator = stranger })
+		}},
+		{name: "validator without vote", rule: "tiers", stake: true, want: ErrValidatorSignature, build: func(t *testing.T, c *testChain) *Block {
+			return c.seal(t, nil, func(h *BlockHeader) { h.Validator = v })
+		}},
+		{name: "validator voted other block", rule: "tiers", stake: true, want: ErrValidatorSignature, build: func(t *testing.T, c *testChain) *Block {
+			return c.seal(t, nil, func(h *BlockHeader) {
+				vote, _ := SignValidatorVote(vk, 1, h.Height-1, Hash{9})
+				h.Validator, h.ValidatorSig = v, vote.SigBytes()
+			})
+		}},
+		{name: "witnesses committed", rule: "tiers", build: func(t *testing.T, c *testChain) *Block {
+			return c.seal(t, nil, func(h *BlockHeader) { h.WitnessCandidates = twoWitnesses(h, wk, wk2) })
+		}},
+		{name: "commitments out of order", rule: "tiers", want: ErrWitnessCandidates, build: func(t *testing.T, c *testChain) *Block {
+			return c.seal(t, nil, func(h *BlockHeader) {
+				ws := twoWitnesses(h, wk, wk2)
+				h.WitnessCandidates = []Witness{ws[1], ws[0]}
+			})
+		}},
+		{name: "commitment signed other block", rule: "tiers", want: ErrWitnessSignature, build: func(t *testing.T, c *testChain) *Block {
+			return c.seal(t, nil, func(h *BlockHeader) {
+				w, _ := SignWitness(wk, h.Height-1, Hash{9})
+				h.WitnessCandidates = []Witness{*w}
+			})
+		}},
+		{name: "witness before any draw", rule: "tiers", want: ErrWrongWitness, build: func(t *testing.T, c *testChain) *Block {
+			return c.seal(t, nil, func(h *BlockHeader) {
+				w, _ := SignWitness(wk, h.Height-1, h.ParentHash)
+				h.Witness, h.WitnessSig = w.Address, w.Signature
+				h.WitnessCandidates = []Witness{*w}
+			})
+		}},
+		{name: "witness signed other block", rule: "tiers", want: ErrWitnessSignature, build: func(t *testing.T, c *testChain) *Block {
+			return c.seal(t, nil, func(h *BlockHeader) {
+				w, _ := SignWitness(wk, h.Height-1, Hash{9})
+				h.Witness, h.WitnessSig = w.Address, w.Signature
+			})
+		}},
+		{name: "signature of another address", rule: "tiers", want: ErrWitnessSignature, build: func(t *testing.T, c *testChain) *Block {
+			return c.seal(t, nil, func(h *BlockHeader) {
+				w, _ := SignWitness(wk, h.Height-1, h.ParentHash)
+				h.Witness, h.WitnessSig = stranger, w.Signature
+			})
+		}},
+		{name: "signed by proposer", rule: "signature", build: func(t *testing.T, c *testChain) *Block {
+			return c.seal(t, nil, nil)
+		}},
+		{name: "changed after signing", rule: "signature", want: ErrHeaderSignature, build: func(t *testing.T, c *testChain) *Block {
+			b := c.seal(t, nil, nil)
+			b.Header.GasUsed++
+			return b
+		}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.rule+"/"+tc.name, func(t *testing.T) {
+			var c *testChain
+			if tc.stake {
+				c = newTestChain(t, rich, ValidatorStake{Address: v, Stake: big.NewInt(1000)})
+			} else {
+				c = newTestChain(t, rich)
+			}
+			b := tc.build(t, c)
+			if err := c.chain.AddBlock(b); err != tc.want {
+				t.Fatalf("got %v, want %v", err, tc.want)
+			}
+			if tc.want == nil && c.chain.Head().Hash() != b.Hash() {
+				t.Fatal("valid block not added")
+			}
+		})
+	}
+}
+
+func TestWitnessSelectionRules(t *testing.T) {
+	_, rich := newTestKey(t)
+	wk, _, _ := GenerateKey()
+	wk2, _, _ := GenerateKey()
+	outsider, _, _ := GenerateKey()
+
+	// The witnesses committed at height 1 are the candidates of epoch 2,
+	// which starts at height 8.
+	setup := func(t *testing.T) (*testChain, Address) {
+		c := newTestChain(t, rich)
+		b := c.sealAt(t, nil, 1, func(h *BlockHeader) { h.WitnessCandidates = twoWitnesses(h, wk, wk2) })
+		if err := c.chain.AddBlock(b); err != nil {
+			t.Fatal(err)
+		}
+		for ts := int64(2); ts <= 7; ts++ {
+			c.mine(t, nil, Address{}, ts)
+		}
+		want, ok := c.chain.selectedWitness(c.chain.Head().Hash(), 8)
+		if !ok {
+			t.Fatal("no witness drawn")
+		}
+		return c, want
+	}
+	witness := func(k *ecdsa.PrivateKey) func(h *BlockHeader) {
+		return func(h *BlockHeader) {
+			w, _ := SignWitness(k, h.Height-1, h.ParentHash)
+			h.Witness, h.WitnessSig = w.Address, w.Signature
+		}
+	}
+	keyOf := func(a Address) (*ecdsa.PrivateKey, *ecdsa.PrivateKey) {
+		if PrivateKeyToAddress(wk) == a {
+			return wk, wk2
+		}
+		return wk2, wk
+	}
+
+	cases := []struct {
+		name string
+		want error
+		mod  func(want Address) func(h *BlockHeader)
+	}{
+		{name: "selected witness", mod: func(a Address) func(*BlockHeader) {
+			k, _ := keyOf(a)
+			return witness(k)
+		}},
+		{name: "no witness", mod: func(Address) func(*BlockHeader) { return nil }},
+		{name: "other candidate", want: ErrWrongWitness, mod: func(a Address) func(*BlockHeader) {
+			_, other := keyOf(a)
+			return witness(other)
+		}},
+		// Committing itself in the block does not make a witness eligible.
+		{name: "uncommitted witness", want: ErrWrongWitness, mod: func(Address) func(*BlockHeader) {
+			return func(h *BlockHeader) {
+				witness(outsider)(h)
+				w, _ := SignWitness(outsider, h.Height-1, h.ParentHash)
+				h.WitnessCandidates = []Witness{*w}
+			}
+		}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			c, want := setup(t)
+			b := c.sealAt(t, nil, 8, tc.mod(want))
+			if err := c.chain.AddBlock(b); err != tc.want {
+				t.Fatalf("got %v, want %v", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestCalcGasLimit(t *testing.T) {
+	parent := uint64(30_000_000)
+	if g := CalcGasLimit(parent, 10_000_000); g != parent-parent/GasLimitBoundDivisor+1 {
+		t.Fatalf("lowered to %d", g)
+	}
+	if g := CalcGasLimit(parent, parent+10); g != parent+10 {
+		t.Fatalf("raised to %d", g)
+	}
+	if g := CalcGasLimit(parent, 0); g != parent {
+		t.Fatalf("no target moved to %d", g)
+	}
+	if err := verifyGasLimit(CalcGasLimit(parent, 10_000_000), &BlockHeader{GasLimit: parent}); err != nil {
+		t.Fatal(err)
+	}
+}
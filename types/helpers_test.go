@@ -0,0 +1,169 @@
+// SPDX-License-Identifier: MIT
+// Dev: KryperAI
+
+package types
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+
+	"krypper-chain/storage"
+)
+
+// testGenesisTime is the timestamp of test genesis blocks; mined blocks
+// are offset from it.
+const testGenesisTime = 1700000000
+
+var (
+	// testKeys maps every key made by newTestKey to its address, so mine
+	// can sign as whichever validator the schedule picks.
+	testKeys = map[Address]*ecdsa.PrivateKey{}
+	// testDefaultKey signs blocks while no validator is staked.
+	testDefaultKey, _, _ = GenerateKey()
+)
+
+func newTestKey(t testing.TB) (*ecdsa.PrivateKey, Address) {
+	t.Helper()
+	k, a, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	testKeys[a] = k
+	return k, a
+}
+
+// testChain is a chain on an in-memory database with a mempool attached.
+type testChain struct {
+	state *StateDB
+	exec  *Executor
+	chain *Blockchain
+	pool  *Mempool
+}
+
+// testConfig is the config of test chains: chain ID 1, three validator
+// slots and four-block epochs.
+var testConfig = ChainConfig{ChainID: 1, ValidatorCount: 3, EpochLength: 4}
+
+// newTestChain creates a chain on an in-memory database. rich is funded
+// with 1000 coins and stakes are applied before genesis.
+func newTestChain(t testing.TB, rich Address, stakes ...ValidatorStake) *testChain {
+	t.Helper()
+	return newTestChainOn(t, storage.NewMemoryDB(), rich, stakes...)
+}
+
+// newTestChainOn is newTestChain on the given empty database.
+func newTestChainOn(t testing.TB, db storage.Database, rich Address, stakes ...ValidatorStake) *testChain {
+	t.Helper()
+	return newTestChainAlloc(t, db, func(state *StateDB) {
+		state.Mint(rich, new(big.Int).Mul(big.NewInt(1000), big.NewInt(1e18)))
+		for _, v := range stakes {
+			state.SetStake(v.Address, v.Stake)
+		}
+	})
+}
+
+// newTestChainAlloc creates a chain on the given empty database whose
+// genesis state is set up by alloc.
+func newTestChainAlloc(t testing.TB, db storage.Database, alloc func(*StateDB)) *testChain {
+	t.Helper()
+	state, err := NewStateDBFromDB(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exec := NewExecutor(state, testConfig)
+	chain := NewBlockchain(state, exec)
+	alloc(state)
+	genesis := NewBlock(&BlockHeader{
+		ChainID:   1,
+		Timestamp: testGenesisTime,
+		StateRoot: state.StateRoot(),
+		GasLimit:  30_000_000,
+		BaseFee:   big.NewInt(0),
+	}, nil)
+	if err := chain.AddBlock(genesis); err != nil {
+		t.Fatal(err)
+	}
+	pool := NewMempool(state, 1)
+	chain.SetMempool(pool)
+	return &testChain{state: state, exec: exec, chain: chain, pool: pool}
+}
+
+// nextHeader returns an unsigned header extending the head ts seconds
+// after genesis, signed for by the scheduled proposer. A validator made by
+// newTestKey attests the parent.
+func (c *testChain) nextHeader(validator Address, ts int64) (*BlockHeader, *ecdsa.PrivateKey) {
+	head := c.chain.Head()
+	h := &BlockHeader{
+		ChainID:    1,
+		ParentHash: head.Hash(),
+		Height:     head.Header.Height + 1,
+		Timestamp:  testGenesisTime + ts,
+		GasLimit:   30_000_000,
+		Validator:  validator,
+		BaseFee:    CalcBaseFee(head.Header),
+	}
+	if k, ok := testKeys[validator]; ok {
+		attest(h, k)
+	}
+	key := testDefaultKey
+	if p, ok := c.chain.ProposerAt(h.Height, 0); ok {
+		key = testKeys[p]
+	}
+	h.Proposer = PrivateKeyToAddress(key)
+	return h, key
+}
+
+// attest makes key's address the Tier-2 validator of h, carrying its vote
+// for the parent.
+func attest(h *BlockHeader, key *ecdsa.PrivateKey) {
+	vote, _ := SignValidatorVote(key, h.ChainID, h.Height-1, h.ParentHash)
+	h.Validator, h.ValidatorSig = vote.Voter, vote.SigBytes()
+}
+
+// mine executes txs on top of the head, seals the block and adds it.
+func (c *testChain) mine(t testing.TB, txs []*Transaction, validator Address, ts int64) *Block {
+	t.Helper()
+	b := c.build(t, txs, validator, ts)
+	if err := c.chain.AddBlock(b); err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+// build executes txs on top of the head and returns the sealed block
+// without adding it.
+func (c *testChain) build(t testing.TB, txs []*Transaction, validator Address, ts int64) *Block {
+	t.Helper()
+	h, key := c.nextHeader(validator, ts)
+
+	snap := c.state.Snapshot()
+	c.exec.SetCurrentHeader(h)
+	for _, tx := range txs {
+		if _, err := c.exec.ExecuteTx(tx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	c.exec.ApplyBlockReward()
+	h.GasUsed = c.exec.GasUsed()
+	h.ReceiptRoot = ReceiptRoot(c.exec.BlockReceipts())
+	h.StateRoot = c.state.StateRoot()
+	c.state.RevertToSnapshot(snap)
+
+	b := NewBlock(h, txs)
+	b.ComputeTxRoot()
+	if err := SignHeader(h, key); err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+// signedTransfer returns a legacy transfer signed by key.
+func signedTransfer(t testing.TB, key *ecdsa.PrivateKey, nonce uint64, to Address, value, gasPrice int64) *Transaction {
+	t.Helper()
+	tx := NewTransferTx(1, nonce, to, big.NewInt(value), big.NewInt(gasPrice), TxGas, nil)
+	if err := SignTransaction(tx, key); err != nil {
+		t.Fatal(err)
+	}
+	return tx
+}
@@ -0,0 +1,167 @@
+// SPDX-License-Identifier: MIT
+// Dev: KryperAI
+
+package types
+
+import (
+	"errors"
+	"math/big"
+
+	"krypper-chain/storage"
+)
+
+// Every block after genesis mints a subsidy of ChainConfig.BlockReward,
+// split between the tiers and the reward pool by the Share* settings like
+// tx fees. Shares of absent tiers and the remainder above the configured
+// shares are never minted. Fees that are not paid out are burned.
+
+// DefaultRewardReductionPercent is used when
+// ChainConfig.RewardReductionPercent is unset: the reward halves.
+const DefaultRewardReductionPercent uint64 = 50
+
+// Issuance records the supply change of one block.
+type Issuance struct {
+	Reward *big.Int `json:"reward"` // subsidy minted by the block
+	Burned *big.Int `json:"burned"` // fees destroyed by the block
+	Supply *big.Int `json:"supply"` // total supply after the block
+}
+
+// BlockReward returns the subsidy of the block at height. Every
+// RewardReductionInterval blocks the reward drops by
+// RewardReductionPercent; an interval of 0 keeps it constant.
+func (c *ChainConfig) BlockReward(height uint64) *big.Int {
+	if height == 0 || c.BaseReward == nil {
+		return big.NewInt(0)
+	}
+	reward := new(big.Int).Set(c.BaseReward)
+	if c.RewardReductionInterval == 0 {
+		return reward
+	}
+
+	pct := c.RewardReductionPercent
+	if pct == 0 {
+		pct = DefaultRewardReductionPercent
+	}
+	keep := big.NewInt(int64(100 - pct))
+	for i := (height - 1) / c.RewardReductionInterval; i > 0 && reward.Sign() > 0; i-- {
+		reward.Mul(reward, keep)
+		reward.Div(reward, big.NewInt(100))
+	}
+	return reward
+}
+
+// distribute pays amount out to the current header's tiers and the reward
+// pool by the configured shares and returns the total paid.
+func (e *Executor) distribute(amount *big.Int) *big.Int {
+	paid := big.NewInt(0)
+	pay := func(to Address, pct uint64) {
+		share := calcPct(amount, pct)
+		if share.Sign() > 0 && !to.IsZero() {
+			e.state.AddBalance(to, share)
+			paid.Add(paid, share)
+		}
+	}
+	pay(e.current.Proposer, e.config.ShareTier1)
+	pay(e.current.Validator, e.config.ShareTier2)
+	pay(e.current.Witness, e.config.ShareTier3)
+	pay(e.config.RewardPool, e.config.SharePool)
+	return paid
+}
+
+// ApplyBlockReward mints the subsidy of the current block. It runs after
+// the block's transactions.
+func (e *Executor) ApplyBlockReward() {
+	if e.current == nil {
+		return
+	}
+	e.reward = e.distribute(e.config.BlockReward(e.current.Height))
+}
+
+// BlockIssuance returns the subsidy minted and fees burned by the current
+// block so far.
+func (e *Executor) BlockIssuance() (reward, burned *big.Int) {
+	return new(big.Int).Set(e.reward), new(big.Int).Set(e.burned)
+}
+
+// TotalSupply sums every balance, stake, delegation and unbonding entry.
+// It walks the whole trie; block-by-block supply is kept in Issuance.
+func (s *StateDB) TotalSupply() (*big.Int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.flush(); err != nil {
+		return nil, err
+	}
+
+	var (
+		total  = big.NewInt(0)
+		decErr error
+	)
+	err := s.trie.Iterate(func(_ Hash, value []byte) bool {
+		acc, err := DecodeAccount(value)
+		if err != nil {
+			decErr = err
+			return false
+		}
+		total.Add(total, acc.Balance)
+		total.Add(total, acc.Stake)
+		for _, d := range acc.Delegations {
+			total.Add(total, d.Amount)
+		}
+		for _, u := range acc.Unbonding {
+			total.Add(total, u.Amount)
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return total, decErr
+}
+
+// IssuanceAt returns the issuance record of the canonical block at height.
+func (bc *Blockchain) IssuanceAt(height uint64) (*Issuance, error) {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	b := bc.canonicalAt(height)
+	if b == nil {
+		return nil, errors.New("unknown height")
+	}
+	return bc.issuanceOf(b.Hash())
+}
+
+// issuanceOf returns the issuance record of a block.
+// Caller must hold bc.mu.
+func (bc *Blockchain) issuanceOf(h Hash) (*Issuance, error) {
+	if is, ok := bc.issuance[h]; ok {
+		return is, nil
+	}
+	return ReadIssuance(bc.db, h)
+}
+
+// stageIssuance writes the issuance record of the block just executed into
+// the batch. Caller must hold bc.mu and state must reflect b.
+func (bc *Blockchain) stageIssuance(w storage.Writer, b *Block) error {
+	reward, burned := bc.executor.BlockIssuance()
+	is := &Issuance{Reward: reward, Burned: burned}
+
+	parent, err := bc.issuanceOf(b.Header.ParentHash)
+	switch {
+	case b.Header.Height > 0 && err == nil:
+		is.Supply = new(big.Int).Add(parent.Supply, reward)
+		is.Supply.Sub(is.Supply, burned)
+	case b.Header.Height == 0 || errors.Is(err, storage.ErrNotFound):
+		// Genesis, or a chain written before issuance was tracked.
+		if is.Supply, err = bc.state.TotalSupply(); err != nil {
+			return err
+		}
+	default:
+		return err
+	}
+
+	if err := WriteIssuance(w, b.Hash(), is); err != nil {
+		return err
+	}
+	bc.issuance[b.Hash()] = is
+	return nil
+}
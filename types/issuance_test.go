@@ -0,0 +1,66 @@
+// SPDX-License-Identifier: MIT
+// Dev: KryperAI
+
+package types
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestBlockRewardSchedule(t *testing.T) {
+	cfg := ChainConfig{BaseReward: big.NewInt(1000), RewardReductionInterval: 2, RewardReductionPercent: 50}
+	want := []int64{0, 1000, 1000, 500, 500, 250, 250, 125}
+	for h, w := range want {
+		if got := cfg.BlockReward(uint64(h)); got.Int64() != w {
+			t.Errorf("height %d: reward %s, want %d", h, got, w)
+		}
+	}
+	if (&ChainConfig{}).BlockReward(1).Sign() != 0 {
+		t.Fatal("reward without base_reward")
+	}
+}
+
+func TestIssuanceTracksSupply(t *testing.T) {
+	key, rich := newTestKey(t)
+	c := newTestChain(t, rich)
+	cfg := &c.exec.config
+	cfg.BaseReward = big.NewInt(1000)
+	cfg.RewardReductionInterval = 2
+	cfg.RewardReductionPercent = 50
+	// 5% of fees is left unassigned and burned.
+	cfg.ShareTier1, cfg.ShareTier2, cfg.ShareTier3, cfg.SharePool = 60, 25, 5, 5
+	cfg.RewardPool[0] = 0xAA
+
+	var to Address
+	to[0] = 9
+	prev, _ := c.state.TotalSupply()
+	for i := int64(1); i <= 5; i++ {
+		tx := signedTransfer(t, key, uint64(i-1), to, 1, 3)
+		c.mine(t, []*Transaction{tx}, Address{}, i)
+
+		is, err := c.chain.IssuanceAt(uint64(i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		// No Tier-2 or Tier-3 address: only their shares go unminted.
+		if want := calcPct(cfg.BlockReward(uint64(i)), 65); is.Reward.Cmp(want) != 0 {
+			t.Fatalf("height %d: reward %s, want %s", i, is.Reward, want)
+		}
+		total, err := c.state.TotalSupply()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if is.Supply.Cmp(total) != 0 {
+			t.Fatalf("height %d: recorded supply %s, state holds %s", i, is.Supply, total)
+		}
+		if is.Burned.Sign() <= 0 {
+			t.Fatalf("height %d: nothing burned", i)
+		}
+		want := new(big.Int).Add(prev, is.Reward)
+		if want.Sub(want, is.Burned); want.Cmp(total) != 0 {
+			t.Fatalf("height %d: supply %s, want previous + reward - burned = %s", i, total, want)
+		}
+		prev = total
+	}
+}
@@ -0,0 +1,64 @@
+// SPDX-License-Identifier: MIT
+// Dev: KryperAI
+
+package types
+
+// journalEntry records the value an account had before a mutation.
+// prev == nil means the account did not exist.
+type journalEntry struct {
+	addr Address
+	prev *Account
+}
+
+// journal is the undo log behind StateDB snapshots. Each snapshot ID maps
+// to the journal length at the time it was taken, so reverting only walks
+// the accounts touched since then.
+type journal struct {
+	entries   []journalEntry
+	revisions []int
+}
+
+func newJournal() *journal {
+	return &journal{
+		entries:   make([]journalEntry, 0),
+		revisions: make([]int, 0),
+	}
+}
+
+// active reports whether any snapshot is open; without one there is
+// nothing to undo and mutations are not recorded.
+func (j *journal) active() bool {
+	return len(j.revisions) > 0
+}
+
+func (j *journal) append(addr Address, prev *Account) {
+	j.entries = append(j.entries, journalEntry{addr: addr, prev: prev})
+}
+
+func (j *journal) snapshot() int {
+	j.revisions = append(j.revisions, len(j.entries))
+	return len(j.revisions) - 1
+}
+
+func (j *journal) valid(id int) bool {
+	return id >= 0 && id < len(j.revisions)
+}
+
+// discard drops snapshot id and every snapshot nested inside it.
+func (j *journal) discard(id int) {
+	j.revisions = j.revisions[:id]
+	if len(j.revisions) == 0 {
+		j.entries = j.entries[:0]
+	}
+}
+
+// revert undoes all entries recorded since snapshot id, newest first,
+// calling undo for each, then drops the snapshot.
+func (j *journal) revert(id int, undo func(journalEntry)) {
+	start := j.revisions[id]
+	for i := len(j.entries) - 1; i >= start; i-- {
+		undo(j.entries[i])
+	}
+	j.entries = j.entries[:start]
+	j.discard(id)
+}
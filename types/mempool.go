@@ -4,106 +4,420 @@
 package types
 
 import (
+	"bytes"
+	"container/heap"
 	"errors"
+	"fmt"
+	"math"
 	"math/big"
 	"sort"
 	"sync"
 )
 
+// DefaultPriceBump is the minimum % increase of both fee caps a
+// transaction needs to replace a pooled one with the same sender and nonce.
+const DefaultPriceBump uint64 = 10
+
+// ErrReplaceUnderpriced is returned when a same-nonce tx does not raise the
+// fees enough to replace the pooled one.
+var ErrReplaceUnderpriced = errors.New("replacement transaction underpriced")
+
+// BumpFee returns fee raised by percent, rounded up.
+func BumpFee(fee *big.Int, percent uint64) *big.Int {
+	out := new(big.Int).Mul(fee, new(big.Int).SetUint64(100+percent))
+	out.Add(out, big.NewInt(99))
+	return out.Div(out, big.NewInt(100))
+}
+
+// Mempool keeps per-sender nonce queues. A sender's txs whose nonces run
+// contiguously from its state nonce are pending (executable); txs after a
+// nonce gap are queued until the gap is filled or the state catches up.
+// Block selection takes pending txs in nonce order per sender, choosing
+// between senders by tip.
 type Mempool struct {
-	mu      sync.RWMutex
-	pending []*Transaction
-	state   *StateDB
-	maxSize int
+	mu        sync.RWMutex
+	state     *StateDB
+	chainID   uint64
+	maxSize   int
+	priceBump uint64
+	baseFee   *big.Int // base fee of the next block; nil until a head is known
+
+	pending map[Address]*nonceList
+	queued  map[Address]*nonceList
+	all     map[Hash]*Transaction
 }
 
-func NewMempool(state *StateDB) *Mempool {
+func NewMempool(state *StateDB, chainID uint64) *Mempool {
 	return &Mempool{
-		state:   state,
-		maxSize: 5000,
-		pending: make([]*Transaction, 0),
+		state:     state,
+		chainID:   chainID,
+		maxSize:   5000,
+		priceBump: DefaultPriceBump,
+		pending:   make(map[Address]*nonceList),
+		queued:    make(map[Address]*nonceList),
+		all:       make(map[Hash]*Transaction),
 	}
 }
 
+// SetBaseFee sets the base fee of the next block. AddTx rejects txs whose
+// fee cap is below it, since no block could include them.
+func (m *Mempool) SetBaseFee(baseFee *big.Int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.baseFee = baseFee
+}
+
+// SetPriceBump sets the % fee increase required to replace a pooled tx.
+func (m *Mempool) SetPriceBump(percent uint64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.priceBump = percent
+}
+
 func (m *Mempool) AddTx(tx *Transaction) error {
 	if tx == nil {
 		return errors.New("nil tx")
 	}
+	if err := tx.ValidateBasic(); err != nil {
+		return err
+	}
+	if err := tx.CheckChainID(m.chainID); err != nil {
+		return err
+	}
 
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
 	// Detect duplicate
-	txHash := tx.Hash()
-	for _, t := range m.pending {
-		if t.Hash() == txHash {
-			return errors.New("duplicate transaction")
-		}
+	if _, ok := m.all[tx.Hash()]; ok {
+		return errors.New("duplicate transaction")
 	}
 
 	// Recover signer = signature verification
 	from, err := RecoverTxSender(tx)
 	if err != nil {
-		return errors.New("invalid signature")
+		return fmt.Errorf("invalid signature: %w", err)
+	}
+	if err := m.state.CheckMultisig(tx); err != nil {
+		return err
+	}
+	if m.state.IsFrozen(from) {
+		return ErrAccountFrozen
 	}
 
-	// Balance check
-	gasCost := new(big.Int).Mul(new(big.Int).SetUint64(tx.GasLimit), tx.GasPrice)
-	totalCost := new(big.Int).Add(tx.Value, gasCost)
+	if m.baseFee != nil && tx.FeeCap().Cmp(m.baseFee) < 0 {
+		return ErrFeeCapTooLow
+	}
 
-	if m.state.GetBalance(from).Cmp(totalCost) < 0 {
-		return errors.New("insufficient balance")
+	// Balance check
+	if m.state.GetBalance(from).Cmp(tx.Cost()) < 0 {
+		return ErrInsufficientBalance
 	}
 
-	// Nonce check
+	// Nonce check; nonces ahead of the account are queued
 	currentNonce := m.state.GetNonce(from)
-	if tx.Nonce < currentNonce {
-		return errors.New("nonce too low")
+	if err := tx.CheckNonce(currentNonce); errors.Is(err, ErrNonceTooLow) {
+		return err
+	}
+
+	// Replace-by-fee: a same-nonce tx must outbid the pooled one on both
+	// fee caps and takes its place in the pending or queued list.
+	if old, lists := m.lookup(from, tx.Nonce); old != nil {
+		if tx.FeeCap().Cmp(BumpFee(old.FeeCap(), m.priceBump)) < 0 ||
+			tx.TipCap().Cmp(BumpFee(old.TipCap(), m.priceBump)) < 0 {
+			return ErrReplaceUnderpriced
+		}
+		lists[from].put(tx)
+		delete(m.all, old.Hash())
+		m.all[tx.Hash()] = tx
+		return nil
 	}
 
 	// Anti-spam full pool
-	if len(m.pending) >= m.maxSize {
-		m.evictLowestGas()
+	if len(m.all) >= m.maxSize && !m.evict(tx) {
+		return errors.New("mempool full")
 	}
 
-	m.pending = append(m.pending, tx)
+	listFor(m.queued, from).put(tx)
+	m.all[tx.Hash()] = tx
+	m.promote(from, currentNonce)
 	return nil
 }
 
+// Get returns the pooled tx of addr with nonce, or nil.
+func (m *Mempool) Get(addr Address, nonce uint64) *Transaction {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	tx, _ := m.lookup(addr, nonce)
+	return tx
+}
+
+// Has reports whether the tx with hash h is pooled.
+func (m *Mempool) Has(h Hash) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	_, ok := m.all[h]
+	return ok
+}
+
+// Reset drops txs the state has made stale or whose sender was frozen and
+// promotes queued txs that became executable. Call it after the head state
+// changes.
+func (m *Mempool) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	senders := make(map[Address]struct{}, len(m.pending)+len(m.queued))
+	for addr := range m.pending {
+		senders[addr] = struct{}{}
+	}
+	for addr := range m.queued {
+		senders[addr] = struct{}{}
+	}
+	for addr := range senders {
+		if m.state.IsFrozen(addr) {
+			m.dropBelow(addr, math.MaxUint64)
+			continue
+		}
+		nonce := m.state.GetNonce(addr)
+		m.dropBelow(addr, nonce)
+		m.promote(addr, nonce)
+	}
+}
+
+// PopForBlock removes and returns up to n pending txs. Each sender's txs
+// come out in nonce order; between senders the highest effective tip at
+// the pool's base fee goes first.
 func (m *Mempool) PopForBlock(n int) []*Transaction {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	if len(m.pending) == 0 {
+	if len(m.pending) == 0 || n <= 0 {
 		return nil
 	}
 
-	sort.Slice(m.pending, func(i, j int) bool {
-		return m.pending[i].GasPrice.Cmp(m.pending[j].GasPrice) > 0
-	})
-
-	if n > len(m.pending) {
-		n = len(m.pending)
+	// Heads of every sender's pending list, best tip on top.
+	h := &txHeap{runs: make([][]*Transaction, 0, len(m.pending)), tip: m.effectiveTip}
+	for _, list := range m.pending {
+		h.runs = append(h.runs, list.sorted())
 	}
+	heap.Init(h)
 
-	selected := m.pending[:n]
-	m.pending = m.pending[n:]
+	selected := make([]*Transaction, 0, n)
+	for len(selected) < n && h.Len() > 0 {
+		txs := h.runs[0]
+		tx := txs[0]
+		selected = append(selected, tx)
+		if len(txs) > 1 {
+			h.runs[0] = txs[1:]
+			heap.Fix(h, 0)
+		} else {
+			heap.Pop(h)
+		}
 
+		from := tx.GetFrom()
+		m.pending[from].remove(tx.Nonce)
+		if m.pending[from].len() == 0 {
+			delete(m.pending, from)
+		}
+		delete(m.all, tx.Hash())
+	}
 	return selected
 }
 
-func (m *Mempool) evictLowestGas() {
-	if len(m.pending) == 0 {
-		return
+// NextNonce returns the nonce a new transaction from addr should use:
+// one past its last pending tx, or its state nonce.
+func (m *Mempool) NextNonce(addr Address) uint64 {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	next := m.state.GetNonce(addr)
+	if list, ok := m.pending[addr]; ok {
+		for list.get(next) != nil {
+			next++
+		}
 	}
-	sort.Slice(m.pending, func(i, j int) bool {
-		return m.pending[i].GasPrice.Cmp(m.pending[j].GasPrice) < 0
-	})
-	m.pending = m.pending[1:]
+	return next
 }
 
 func (m *Mempool) Count() int {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
-	return len(m.pending)
-}
\ No newline at end of file
+	return len(m.all)
+}
+
+// Stats returns the number of pending and queued txs.
+func (m *Mempool) Stats() (pending, queued int) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, list := range m.pending {
+		pending += list.len()
+	}
+	for _, list := range m.queued {
+		queued += list.len()
+	}
+	return pending, queued
+}
+
+// lookup returns the pooled tx of addr with nonce, if any, and the set
+// (pending or queued) holding it.
+func (m *Mempool) lookup(addr Address, nonce uint64) (*Transaction, map[Address]*nonceList) {
+	for _, lists := range []map[Address]*nonceList{m.pending, m.queued} {
+		if list, ok := lists[addr]; ok {
+			if tx := list.get(nonce); tx != nil {
+				return tx, lists
+			}
+		}
+	}
+	return nil, nil
+}
+
+// promote moves addr's queued txs that continue its pending run (which
+// starts at stateNonce) into pending.
+func (m *Mempool) promote(addr Address, stateNonce uint64) {
+	q, ok := m.queued[addr]
+	if !ok {
+		return
+	}
+	next := stateNonce
+	if p, ok := m.pending[addr]; ok {
+		for p.get(next) != nil {
+			next++
+		}
+	}
+	for {
+		tx := q.get(next)
+		if tx == nil {
+			break
+		}
+		q.remove(next)
+		listFor(m.pending, addr).put(tx)
+		next++
+	}
+	if q.len() == 0 {
+		delete(m.queued, addr)
+	}
+}
+
+// dropBelow removes addr's txs with nonces the state has already used.
+func (m *Mempool) dropBelow(addr Address, nonce uint64) {
+	for _, lists := range []map[Address]*nonceList{m.pending, m.queued} {
+		list, ok := lists[addr]
+		if !ok {
+			continue
+		}
+		for n, tx := range list.txs {
+			if n < nonce {
+				list.remove(n)
+				delete(m.all, tx.Hash())
+			}
+		}
+		if list.len() == 0 {
+			delete(lists, addr)
+		}
+	}
+}
+
+// evict makes room for tx by dropping the queued tx with the lowest
+// effective tip, or else the lowest-tip tail of a pending run (dropping a
+// tail keeps the run contiguous). It reports false if nothing cheaper than
+// tx can go.
+func (m *Mempool) evict(tx *Transaction) bool {
+	var (
+		victim *Transaction
+		from   Address
+		lists  map[Address]*nonceList
+	)
+	consider := func(l map[Address]*nonceList, addr Address, cand *Transaction) {
+		if victim == nil || m.effectiveTip(cand).Cmp(m.effectiveTip(victim)) < 0 {
+			victim, from, lists = cand, addr, l
+		}
+	}
+	for addr, list := range m.queued {
+		for _, cand := range list.txs {
+			consider(m.queued, addr, cand)
+		}
+	}
+	if victim == nil {
+		for addr, list := range m.pending {
+			sorted := list.sorted()
+			consider(m.pending, addr, sorted[len(sorted)-1])
+		}
+	}
+	if victim == nil || m.effectiveTip(victim).Cmp(m.effectiveTip(tx)) >= 0 {
+		return false
+	}
+
+	lists[from].remove(victim.Nonce)
+	if lists[from].len() == 0 {
+		delete(lists, from)
+	}
+	delete(m.all, victim.Hash())
+	return true
+}
+
+// effectiveTip returns the tip per gas tx pays at the pool's base fee,
+// negative once its fee cap no longer covers the base fee, or its tip cap
+// while no base fee is known. Caller must hold m.mu.
+func (m *Mempool) effectiveTip(tx *Transaction) *big.Int {
+	if m.baseFee == nil {
+		return tx.TipCap()
+	}
+	tip := new(big.Int).Sub(tx.FeeCap(), m.baseFee)
+	if tip.Cmp(tx.TipCap()) > 0 {
+		return tx.TipCap()
+	}
+	return tip
+}
+
+// nonceList holds one sender's txs by nonce.
+type nonceList struct {
+	txs map[uint64]*Transaction
+}
+
+func listFor(lists map[Address]*nonceList, addr Address) *nonceList {
+	list, ok := lists[addr]
+	if !ok {
+		list = &nonceList{txs: make(map[uint64]*Transaction)}
+		lists[addr] = list
+	}
+	return list
+}
+
+func (l *nonceList) put(tx *Transaction)           { l.txs[tx.Nonce] = tx }
+func (l *nonceList) get(nonce uint64) *Transaction { return l.txs[nonce] }
+func (l *nonceList) remove(nonce uint64)           { delete(l.txs, nonce) }
+func (l *nonceList) len() int                      { return len(l.txs) }
+
+// sorted returns the txs in nonce order.
+func (l *nonceList) sorted() []*Transaction {
+	out := make([]*Transaction, 0, len(l.txs))
+	for _, tx := range l.txs {
+		out = append(out, tx)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Nonce < out[j].Nonce })
+	return out
+}
+
+// txHeap orders senders' nonce-sorted runs by the tip of their first tx,
+// breaking ties by hash so selection is deterministic.
+type txHeap struct {
+	runs [][]*Transaction
+	tip  func(*Transaction) *big.Int
+}
+
+func (h *txHeap) Len() int { return len(h.runs) }
+func (h *txHeap) Less(i, j int) bool {
+	if c := h.tip(h.runs[i][0]).Cmp(h.tip(h.runs[j][0])); c != 0 {
+		return c > 0
+	}
+	a, b := h.runs[i][0].Hash(), h.runs[j][0].Hash()
+	return bytes.Compare(a[:], b[:]) < 0
+}
+func (h *txHeap) Swap(i, j int) { h.runs[i], h.runs[j] = h.runs[j], h.runs[i] }
+func (h *txHeap) Push(x any)    { h.runs = append(h.runs, x.([]*Transaction)) }
+func (h *txHeap) Pop() any {
+	x := h.runs[len(h.runs)-1]
+	h.runs = h.runs[:len(h.runs)-1]
+	return x
+}
@@ -0,0 +1,169 @@
+// SPDX-License-Identifier: MIT
+// Dev: KryperAI
+
+package types
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestMempoolNonceQueues(t *testing.T) {
+	k1, a1 := newTestKey(t)
+	c := newTestChain(t, a1)
+	k2, a2 := newTestKey(t)
+	c.state.Mint(a2, big.NewInt(1e18))
+	var to Address
+	to[0] = 3
+
+	// Nonce 1 arrives before nonce 0 and waits in the queue.
+	if err := c.pool.AddTx(signedTransfer(t, k1, 1, to, 1, 100)); err != nil {
+		t.Fatal(err)
+	}
+	if p, q := c.pool.Stats(); p != 0 || q != 1 {
+		t.Fatalf("pending %d queued %d", p, q)
+	}
+	c.pool.AddTx(signedTransfer(t, k1, 0, to, 1, 1))
+	c.pool.AddTx(signedTransfer(t, k2, 0, to, 1, 50))
+	c.pool.AddTx(signedTransfer(t, k1, 3, to, 1, 5)) // gap at 2
+	if p, q := c.pool.Stats(); p != 3 || q != 1 {
+		t.Fatalf("pending %d queued %d", p, q)
+	}
+	if n := c.pool.NextNonce(a1); n != 2 {
+		t.Fatalf("next nonce %d", n)
+	}
+
+	// a1's head tx tips 1, so a2 goes first; a1 stays in nonce order even
+	// though its nonce 1 tips more.
+	txs := c.pool.PopForBlock(10)
+	if len(txs) != 3 || txs[0].GetFrom() != a2 || txs[1].Nonce != 0 || txs[2].Nonce != 1 {
+		t.Fatalf("block order: %d txs", len(txs))
+	}
+	c.mine(t, txs, Address{}, 1)
+
+	// Filling the gap promotes the queued tx.
+	c.pool.AddTx(signedTransfer(t, k1, 2, to, 1, 5))
+	if p, q := c.pool.Stats(); p != 2 || q != 0 {
+		t.Fatalf("after gap filled: pending %d queued %d", p, q)
+	}
+}
+
+func TestMempoolResetDropsIncluded(t *testing.T) {
+	key, rich := newTestKey(t)
+	c := newTestChain(t, rich)
+	var to Address
+	tx := signedTransfer(t, key, 0, to, 1, 1)
+	if err := c.pool.AddTx(tx); err != nil {
+		t.Fatal(err)
+	}
+	c.mine(t, []*Transaction{tx}, Address{}, 1)
+	if c.pool.Count() != 0 {
+		t.Fatal("included tx still pooled")
+	}
+	if err := c.pool.AddTx(tx); err != ErrNonceTooLow {
+		t.Fatalf("stale nonce: %v", err)
+	}
+}
+
+func TestReplaceByFee(t *testing.T) {
+	key, rich := newTestKey(t)
+	c := newTestChain(t, rich)
+	var to Address
+
+	if err := c.pool.AddTx(signedTransfer(t, key, 0, to, 1, 100)); err != nil {
+		t.Fatal(err)
+	}
+	// 9% is below the default 10% bump.
+	if err := c.pool.AddTx(signedTransfer(t, key, 0, to, 1, 109)); err != ErrReplaceUnderpriced {
+		t.Fatalf("underpriced replacement: %v", err)
+	}
+	r := signedTransfer(t, key, 0, to, 1, 110)
+	if err := c.pool.AddTx(r); err != nil {
+		t.Fatal(err)
+	}
+	if c.pool.Count() != 1 || c.pool.Get(rich, 0).Hash() != r.Hash() {
+		t.Fatal("replacement not in place of the original")
+	}
+
+	// Queued txs can be replaced too and stay queued.
+	c.pool.AddTx(signedTransfer(t, key, 2, to, 1, 1))
+	if err := c.pool.AddTx(signedTransfer(t, key, 2, to, 1, 2)); err != nil {
+		t.Fatal(err)
+	}
+	if p, q := c.pool.Stats(); p != 1 || q != 1 {
+		t.Fatalf("pending %d queued %d", p, q)
+	}
+}
+
+func TestReplaceByFeeNeedsBothCaps(t *testing.T) {
+	key, rich := newTestKey(t)
+	c := newTestChain(t, rich)
+	var to Address
+	dyn := func(feeCap, tip int64) *Transaction {
+		tx := NewTransferTx(1, 0, to, big.NewInt(1), nil, TxGas, nil)
+		tx.SetDynamicFee(big.NewInt(feeCap), big.NewInt(tip))
+		SignTransaction(tx, key)
+		return tx
+	}
+	c.pool.AddTx(dyn(100, 10))
+	if err := c.pool.AddTx(dyn(200, 10)); err != ErrReplaceUnderpriced {
+		t.Fatalf("tip not bumped: %v", err)
+	}
+	if err := c.pool.AddTx(dyn(100, 20)); err != ErrReplaceUnderpriced {
+		t.Fatalf("fee cap not bumped: %v", err)
+	}
+	if err := c.pool.AddTx(dyn(110, 11)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBumpFee(t *testing.T) {
+	cases := []struct{ fee, pct, want int64 }{
+		{100, 10, 110},
+		{101, 10, 112}, // rounds up
+		{0, 10, 0},
+		{7, 0, 7},
+	}
+	for _, c := range cases {
+		if got := BumpFee(big.NewInt(c.fee), uint64(c.pct)); got.Int64() != c.want {
+			t.Errorf("BumpFee(%d, %d) = %s, want %d", c.fee, c.pct, got, c.want)
+		}
+	}
+}
+
+// Selection and eviction go by the tip a tx pays at the base fee, not by
+// its tip cap.
+func TestMempoolRanksByEffectiveTip(t *testing.T) {
+	_, rich := newTestKey(t)
+	c := newTestChain(t, rich)
+	c.pool.SetBaseFee(big.NewInt(100))
+	c.pool.maxSize = 2
+	dyn := func(feeCap, tip int64) *Transaction {
+		k, a := newTestKey(t)
+		c.state.Mint(a, big.NewInt(1e18))
+		tx := NewTransferTx(1, 0, Address{1}, big.NewInt(1), nil, TxGas, nil)
+		tx.SetDynamicFee(big.NewInt(feeCap), big.NewInt(tip))
+		if err := SignTransaction(tx, k); err != nil {
+			t.Fatal(err)
+		}
+		return tx
+	}
+	capped := dyn(101, 50) // pays 1 above the base fee
+	paying := dyn(200, 10)
+	for _, tx := range []*Transaction{capped, paying} {
+		if err := c.pool.AddTx(tx); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// A full pool evicts the tx paying least, though its tip cap is high.
+	if err := c.pool.AddTx(dyn(200, 5)); err != nil {
+		t.Fatal(err)
+	}
+	if c.pool.Has(capped.Hash()) || !c.pool.Has(paying.Hash()) {
+		t.Fatal("evicted by tip cap")
+	}
+	if txs := c.pool.PopForBlock(1); len(txs) != 1 || txs[0].Hash() != paying.Hash() {
+		t.Fatal("selected by tip cap")
+	}
+}
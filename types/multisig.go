@@ -0,0 +1,310 @@
+// SPDX-License-Identifier: MIT
+// Dev: KryperAI
+
+package types
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"sort"
+
+	gethcrypto "github.com/ethereum/go-ethereum/crypto"
+)
+
+// A multisig account is controlled by a MultisigPolicy: M of N signer
+// addresses. It has no key of its own; its address is derived from the
+// creating account and the nonce of the creation tx (MultisigAddress).
+//
+//   - TxTypeMultisigCreate creates the account with the policy in Data,
+//     funded with Value.
+//   - Any tx with Multisig set is sent from that account: it carries no
+//     regular signature but exactly Threshold Cosignatures over
+//     HashForSign from distinct signers, in signer address order. It uses
+//     the multisig account's nonce and balance.
+//   - TxTypeMultisigUpdate, sent from the multisig account itself, rotates
+//     the signer set and threshold to the policy in Data.
+
+// MaxMultisigSigners caps the size of a signer set.
+const MaxMultisigSigners = 20
+
+var (
+	ErrInvalidMultisigPolicy = errors.New("invalid multisig policy")
+	ErrNotMultisig           = errors.New("account is not a multisig account")
+	ErrMultisigThreshold     = errors.New("not enough multisig signatures")
+	ErrInvalidCosignatures   = errors.New("invalid multisig cosignatures")
+	ErrMultisigSignature     = errors.New("multisig tx must not carry a sender signature")
+	ErrMultisigExists        = errors.New("multisig account already exists")
+)
+
+// MultisigPolicy is the signer set and threshold of a multisig account.
+type MultisigPolicy struct {
+	Signers   []Address `json:"signers"` // sorted, distinct
+	Threshold uint64    `json:"threshold"`
+}
+
+// NewMultisigPolicy returns a policy with signers sorted, or an error if
+// it is not a valid threshold-of-signers policy.
+func NewMultisigPolicy(signers []Address, threshold uint64) (*MultisigPolicy, error) {
+	p := &MultisigPolicy{Signers: append([]Address(nil), signers...), Threshold: threshold}
+	sort.Slice(p.Signers, func(i, j int) bool {
+		return bytes.Compare(p.Signers[i][:], p.Signers[j][:]) < 0
+	})
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Validate checks that 1 <= Threshold <= len(Signers) <= MaxMultisigSigners
+// and that the signers are non-zero, sorted and distinct.
+func (p *MultisigPolicy) Validate() error {
+	if p == nil || p.Threshold == 0 || p.Threshold > uint64(len(p.Signers)) || len(p.Signers) > MaxMultisigSigners {
+		return ErrInvalidMultisigPolicy
+	}
+	for i, s := range p.Signers {
+		if s.IsZero() {
+			return ErrInvalidMultisigPolicy
+		}
+		if i > 0 && bytes.Compare(p.Signers[i-1][:], s[:]) >= 0 {
+			return ErrInvalidMultisigPolicy
+		}
+	}
+	return nil
+}
+
+// isSigner reports whether addr is in the signer set.
+func (p *MultisigPolicy) isSigner(addr Address) bool {
+	i := sort.Search(len(p.Signers), func(i int) bool {
+		return bytes.Compare(p.Signers[i][:], addr[:]) >= 0
+	})
+	return i < len(p.Signers) && p.Signers[i] == addr
+}
+
+func (p *MultisigPolicy) copy() *MultisigPolicy {
+	if p == nil {
+		return nil
+	}
+	return &MultisigPolicy{Signers: append([]Address(nil), p.Signers...), Threshold: p.Threshold}
+}
+
+// EncodeMultisigPolicy returns the canonical encoding used in tx data.
+func EncodeMultisigPolicy(p *MultisigPolicy) ([]byte, error) {
+	return json.Marshal(p)
+}
+
+// DecodeMultisigPolicy decodes and validates a policy from tx data.
+func DecodeMultisigPolicy(data []byte) (*MultisigPolicy, error) {
+	var p MultisigPolicy
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, ErrInvalidMultisigPolicy
+	}
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// MultisigAddress returns the address of the multisig account created by
+// creator's tx with nonce.
+func MultisigAddress(creator Address, nonce uint64) Address {
+	h := sha256.New()
+	var buf [8]byte
+	h.Write([]byte("krypper-multisig"))
+	h.Write(creator[:])
+	binary.BigEndian.PutUint64(buf[:], nonce)
+	h.Write(buf[:])
+
+	var out Address
+	copy(out[:], h.Sum(nil)[12:])
+	return out
+}
+
+// NewMultisigCreateTx builds an unsigned transaction creating a multisig
+// account with policy p, funded with value.
+func NewMultisigCreateTx(chainId uint64, nonce uint64, p *MultisigPolicy, value, gasPrice *big.Int, gasLimit uint64) (*Transaction, error) {
+	data, err := EncodeMultisigPolicy(p)
+	if err != nil {
+		return nil, err
+	}
+	tx := NewTransferTx(chainId, nonce, Address{}, value, gasPrice, gasLimit, data)
+	tx.Type = TxTypeMultisigCreate
+	return tx, nil
+}
+
+// NewMultisigUpdateTx builds a transaction from multisig account from
+// rotating its policy to p. It still needs cosignatures.
+func NewMultisigUpdateTx(chainId uint64, nonce uint64, from Address, p *MultisigPolicy, gasPrice *big.Int, gasLimit uint64) (*Transaction, error) {
+	data, err := EncodeMultisigPolicy(p)
+	if err != nil {
+		return nil, err
+	}
+	tx := NewTransferTx(chainId, nonce, Address{}, nil, gasPrice, gasLimit, data)
+	tx.Type = TxTypeMultisigUpdate
+	tx.SetMultisig(from)
+	return tx, nil
+}
+
+// SetMultisig makes tx a transaction from multisig account from. It must be
+// called before cosigning.
+func (tx *Transaction) SetMultisig(from Address) {
+	tx.Multisig = &from
+	tx.Cosignatures = nil
+	tx.hash = Hash{}
+}
+
+// IsMultisig reports whether tx is sent from a multisig account.
+func (tx *Transaction) IsMultisig() bool {
+	return tx.Multisig != nil
+}
+
+// CosignTransaction adds priv's signature to a multisig tx, keeping the
+// cosignatures in signer address order.
+func CosignTransaction(tx *Transaction, priv *ecdsa.PrivateKey) error {
+	if tx == nil || !tx.IsMultisig() {
+		return ErrNotMultisig
+	}
+	if priv == nil {
+		return errors.New("nil private key")
+	}
+	if err := tx.ValidateBasic(); err != nil {
+		return err
+	}
+	cosigners, err := recoverCosigners(tx)
+	if err != nil {
+		return err
+	}
+	addr := PrivateKeyToAddress(priv)
+	i := sort.Search(len(cosigners), func(i int) bool {
+		return bytes.Compare(cosigners[i][:], addr[:]) >= 0
+	})
+	if i < len(cosigners) && cosigners[i] == addr {
+		return errors.New("already cosigned")
+	}
+	payload := tx.HashForSign()
+	sig, err := gethcrypto.Sign(payload[:], priv)
+	if err != nil {
+		return err
+	}
+	tx.Cosignatures = append(tx.Cosignatures, nil)
+	copy(tx.Cosignatures[i+1:], tx.Cosignatures[i:])
+	tx.Cosignatures[i] = sig
+	tx.hash = Hash{}
+	return nil
+}
+
+// recoverCosigners returns the addresses that cosigned tx. They must be
+// distinct and in ascending order, and a multisig tx carries no sender
+// signature, so its hash has a single valid encoding per set of cosigners.
+func recoverCosigners(tx *Transaction) ([]Address, error) {
+	if tx.Signature.R != nil && tx.Signature.R.Sign() != 0 ||
+		tx.Signature.S != nil && tx.Signature.S.Sign() != 0 || tx.Signature.V != 0 {
+		return nil, ErrMultisigSignature
+	}
+	payload := tx.HashForSign()
+	out := make([]Address, 0, len(tx.Cosignatures))
+	for _, sig := range tx.Cosignatures {
+		if len(sig) != 65 {
+			return nil, errors.New("cosignature must be 65 bytes")
+		}
+		pub, err := gethcrypto.SigToPub(payload[:], sig)
+		if err != nil {
+			return nil, err
+		}
+		addr := PubKeyToAddress(pub)
+		if n := len(out); n > 0 && bytes.Compare(out[n-1][:], addr[:]) >= 0 {
+			return nil, ErrInvalidCosignatures
+		}
+		out = append(out, addr)
+	}
+	return out, nil
+}
+
+// MultisigPolicy returns the policy of addr, or nil if it is not a
+// multisig account.
+func (s *StateDB) MultisigPolicy(addr Address) *MultisigPolicy {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	acc := s.getAccount(addr)
+	if acc == nil {
+		return nil
+	}
+	return acc.Multisig.copy()
+}
+
+// CheckMultisig verifies the stateful part of a tx's authorization: a
+// multisig tx needs Threshold cosignatures from its account's signers and
+// no others, so no cosignature can be added to change its hash. Call it
+// after RecoverTxSender.
+func (s *StateDB) CheckMultisig(tx *Transaction) error {
+	if !tx.IsMultisig() {
+		return nil
+	}
+	p := s.MultisigPolicy(*tx.Multisig)
+	if p == nil {
+		return ErrNotMultisig
+	}
+	cosigners, err := recoverCosigners(tx)
+	if err != nil {
+		return err
+	}
+	for _, c := range cosigners {
+		if !p.isSigner(c) {
+			return ErrInvalidCosignatures
+		}
+	}
+	n := uint64(len(cosigners))
+	if n < p.Threshold {
+		return ErrMultisigThreshold
+	}
+	if n > p.Threshold {
+		return ErrInvalidCosignatures
+	}
+	return nil
+}
+
+// setMultisig sets the policy of addr.
+func (s *StateDB) setMultisig(addr Address, p *MultisigPolicy) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.getOrCreate(addr).Multisig = p.copy()
+}
+
+// applyMultisigCreate creates the multisig account of from's tx and moves
+// the tx value into it.
+func (e *Executor) applyMultisigCreate(from Address, tx *Transaction) error {
+	p, err := DecodeMultisigPolicy(tx.Data)
+	if err != nil {
+		return err
+	}
+	addr := MultisigAddress(from, tx.Nonce)
+	if e.state.MultisigPolicy(addr) != nil || e.state.GetNonce(addr) != 0 {
+		return ErrMultisigExists
+	}
+	e.state.setMultisig(addr, p)
+	if tx.Value.Sign() == 0 {
+		return nil
+	}
+	if err := e.state.SubBalance(from, tx.Value); err != nil {
+		return err
+	}
+	return e.state.AddBalance(addr, tx.Value)
+}
+
+// applyMultisigUpdate rotates the policy of the multisig account from.
+// Its cosignatures were checked against the old policy.
+func (e *Executor) applyMultisigUpdate(from Address, tx *Transaction) error {
+	if e.state.MultisigPolicy(from) == nil {
+		return ErrNotMultisig
+	}
+	p, err := DecodeMultisigPolicy(tx.Data)
+	if err != nil {
+		return err
+	}
+	e.state.setMultisig(from, p)
+	return nil
+}
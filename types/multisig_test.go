@@ -0,0 +1,178 @@
+// SPDX-License-Identifier: MIT
+// Dev: KryperAI
+
+package types
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"math/big"
+	"testing"
+)
+
+// newMultisigChain creates a 2-of-3 multisig account funded with 1 coin by
+// a rich creator and returns its address and signer keys.
+func newMultisigChain(t *testing.T) (*testChain, *ecdsa.PrivateKey, Address, []*ecdsa.PrivateKey) {
+	t.Helper()
+	key, rich := newTestKey(t)
+	c := newTestChain(t, rich)
+	var signers []*ecdsa.PrivateKey
+	var addrs []Address
+	for i := 0; i < 3; i++ {
+		k, a := newTestKey(t)
+		signers, addrs = append(signers, k), append(addrs, a)
+	}
+	p, err := NewMultisigPolicy(addrs, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx, _ := NewMultisigCreateTx(1, 0, p, big.NewInt(1e18), big.NewInt(1), 0)
+	tx.GasLimit = IntrinsicGas(tx)
+	if err := SignTransaction(tx, key); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.pool.AddTx(tx); err != nil {
+		t.Fatal(err)
+	}
+	c.mine(t, []*Transaction{tx}, Address{}, 1)
+	if r, _, _ := c.chain.GetReceipt(tx.Hash()); !r.Success {
+		t.Fatalf("create failed: %s", r.Error)
+	}
+	ms := MultisigAddress(rich, 0)
+	if c.state.MultisigPolicy(ms) == nil || c.state.GetBalance(ms).Cmp(big.NewInt(1e18)) != 0 {
+		t.Fatal("multisig account not created")
+	}
+	return c, key, ms, signers
+}
+
+// multisigTransfer returns a transfer of 5 wei from ms cosigned by keys.
+func multisigTransfer(t *testing.T, ms Address, nonce uint64, keys ...*ecdsa.PrivateKey) *Transaction {
+	t.Helper()
+	tx := NewTransferTx(1, nonce, Address{7}, big.NewInt(5), big.NewInt(1), 0, nil)
+	tx.SetMultisig(ms)
+	tx.GasLimit = IntrinsicGas(tx) + uint64(len(keys))*TxCosignatureGas
+	for _, k := range keys {
+		if err := CosignTransaction(tx, k); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return tx
+}
+
+func TestMultisigThreshold(t *testing.T) {
+	c, _, ms, signers := newMultisigChain(t)
+	if err := c.pool.AddTx(multisigTransfer(t, ms, 0, signers[0])); err == nil {
+		t.Fatal("one signature accepted")
+	}
+	tx := multisigTransfer(t, ms, 0, signers[0], signers[2])
+	if err := c.pool.AddTx(tx); err != nil {
+		t.Fatal(err)
+	}
+	c.mine(t, []*Transaction{tx}, Address{}, 2)
+	if c.state.GetBalance(Address{7}).Int64() != 5 {
+		t.Fatal("transfer not applied")
+	}
+}
+
+// The multisig error behind a failed sender recovery reaches the caller.
+func TestMultisigUncosignedError(t *testing.T) {
+	c, _, ms, _ := newMultisigChain(t)
+	tx := multisigTransfer(t, ms, 0)
+	if err := c.pool.AddTx(tx); !errors.Is(err, ErrMultisigThreshold) {
+		t.Fatalf("mempool: %v", err)
+	}
+	h, _ := c.nextHeader(Address{}, 2)
+	c.exec.SetCurrentHeader(h)
+	if _, err := c.exec.ExecuteTx(tx); !errors.Is(err, ErrMultisigThreshold) {
+		t.Fatalf("executor: %v", err)
+	}
+}
+
+func TestMultisigRejectsOutsiders(t *testing.T) {
+	c, key, ms, signers := newMultisigChain(t)
+	outsider, _ := newTestKey(t)
+	if err := c.pool.AddTx(multisigTransfer(t, ms, 0, signers[0], outsider)); err == nil {
+		t.Fatal("outsider signature counted")
+	}
+
+	// A single-key account cannot be spent as a multisig account.
+	f := NewTransferTx(1, 1, Address{7}, big.NewInt(5), big.NewInt(1), 100_000, nil)
+	f.SetMultisig(PrivateKeyToAddress(key))
+	CosignTransaction(f, key)
+	if err := c.pool.AddTx(f); err == nil {
+		t.Fatal("forged multisig accepted")
+	}
+}
+
+// Relayers cannot change the hash of a valid multisig tx.
+func TestMultisigCosignaturesNotMalleable(t *testing.T) {
+	c, _, ms, signers := newMultisigChain(t)
+	outsider, _ := newTestKey(t)
+
+	withOutsider := multisigTransfer(t, ms, 0, signers[0], signers[2], outsider)
+	if err := c.pool.AddTx(withOutsider); !errors.Is(err, ErrInvalidCosignatures) {
+		t.Fatalf("outsider cosignature: %v", err)
+	}
+	extra := multisigTransfer(t, ms, 0, signers[0], signers[1], signers[2])
+	if err := c.pool.AddTx(extra); !errors.Is(err, ErrInvalidCosignatures) {
+		t.Fatalf("cosignatures beyond threshold: %v", err)
+	}
+	reordered := multisigTransfer(t, ms, 0, signers[0], signers[2])
+	reordered.Cosignatures[0], reordered.Cosignatures[1] = reordered.Cosignatures[1], reordered.Cosignatures[0]
+	if err := c.pool.AddTx(reordered); !errors.Is(err, ErrInvalidCosignatures) {
+		t.Fatalf("reordered cosignatures: %v", err)
+	}
+	junk := multisigTransfer(t, ms, 0, signers[0], signers[2])
+	junk.Signature.V = 1
+	if err := c.pool.AddTx(junk); !errors.Is(err, ErrMultisigSignature) {
+		t.Fatalf("sender signature: %v", err)
+	}
+
+	// Cosigning in any order gives the same tx.
+	a := multisigTransfer(t, ms, 0, signers[0], signers[2])
+	b := multisigTransfer(t, ms, 0, signers[2], signers[0])
+	if a.Hash() != b.Hash() {
+		t.Fatal("cosigning order changes the hash")
+	}
+	if err := c.pool.AddTx(b); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMultisigRotation(t *testing.T) {
+	c, _, ms, signers := newMultisigChain(t)
+	k4, a4 := newTestKey(t)
+	p, _ := NewMultisigPolicy([]Address{a4}, 1)
+	up, _ := NewMultisigUpdateTx(1, 0, ms, p, big.NewInt(1), 0)
+	up.GasLimit = IntrinsicGas(up) + 2*TxCosignatureGas
+	CosignTransaction(up, signers[0])
+	CosignTransaction(up, signers[1])
+	c.mine(t, []*Transaction{up}, Address{}, 2)
+	if got := c.state.MultisigPolicy(ms); got == nil || got.Threshold != 1 {
+		t.Fatal("policy not rotated")
+	}
+
+	// Only the new signer set counts from now on.
+	if err := c.pool.AddTx(multisigTransfer(t, ms, 1, signers[0], signers[1])); err == nil {
+		t.Fatal("old signers accepted")
+	}
+	if err := c.pool.AddTx(multisigTransfer(t, ms, 1, k4)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Replacing a multisig tx with higher fees needs new cosignatures.
+func TestMultisigFeeChangeNeedsNewCosignatures(t *testing.T) {
+	c, _, ms, signers := newMultisigChain(t)
+	tx := multisigTransfer(t, ms, 0, signers[0], signers[1])
+	tx.SetDynamicFee(big.NewInt(10), big.NewInt(2))
+	if err := c.pool.AddTx(tx); err == nil {
+		t.Fatal("stale cosignatures accepted")
+	}
+	tx.SetMultisig(ms)
+	CosignTransaction(tx, signers[0])
+	CosignTransaction(tx, signers[1])
+	if err := c.pool.AddTx(tx); err != nil {
+		t.Fatal(err)
+	}
+}
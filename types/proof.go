@@ -0,0 +1,163 @@
+// SPDX-License-Identifier: MIT
+// Dev: KryperAI
+
+package types
+
+import (
+	"crypto/sha256"
+	"errors"
+)
+
+// TrieProof is a Merkle path for one key of a Trie.
+//
+// Siblings are ordered from the root downward. The path ends either in a
+// leaf (LeafKey/LeafValue set) or in an empty subtree (both unset). If the
+// leaf key differs from the proven key, the proof shows the key is absent.
+type TrieProof struct {
+	Siblings  []Hash `json:"siblings"`
+	LeafKey   Hash   `json:"leafKey"`
+	LeafValue []byte `json:"leafValue"`
+}
+
+// AccountProof proves an Account (or its absence) against a state root.
+type AccountProof struct {
+	Address   Address   `json:"address"`
+	StateRoot Hash      `json:"stateRoot"`
+	Proof     TrieProof `json:"proof"`
+}
+
+// Prove builds a proof for key against the current root.
+func (t *Trie) Prove(key Hash) (*TrieProof, error) {
+	proof := &TrieProof{Siblings: make([]Hash, 0)}
+
+	n := t.root
+	for depth := 0; ; depth++ {
+		var err error
+		if n, err = t.resolve(n); err != nil {
+			return nil, err
+		}
+		switch node := n.(type) {
+		case nil:
+			return proof, nil
+		case *trieLeaf:
+			proof.LeafKey = node.key
+			proof.LeafValue = append([]byte{}, node.value...)
+			return proof, nil
+		case *trieBranch:
+			if keyBit(key, depth) == 0 {
+				proof.Siblings = append(proof.Siblings, nodeHash(node.right))
+				n = node.left
+			} else {
+				proof.Siblings = append(proof.Siblings, nodeHash(node.left))
+				n = node.right
+			}
+		default:
+			return nil, errors.New("trie: unknown node")
+		}
+	}
+}
+
+// VerifyTrieProof checks proof for key against root. It returns the proven
+// value, or nil if the proof shows the key is absent.
+func VerifyTrieProof(root Hash, key Hash, proof *TrieProof) ([]byte, error) {
+	if proof == nil {
+		return nil, errors.New("nil proof")
+	}
+	depth := len(proof.Siblings)
+	if depth > 256 {
+		return nil, errors.New("proof too deep")
+	}
+
+	var (
+		cur   Hash
+		value []byte
+	)
+	if len(proof.LeafValue) > 0 {
+		// A different leaf at this position must share the path prefix.
+		for i := 0; i < depth; i++ {
+			if keyBit(proof.LeafKey, i) != keyBit(key, i) {
+				return nil, errors.New("proof leaf is off path")
+			}
+		}
+		leaf := &trieLeaf{key: proof.LeafKey, value: proof.LeafValue}
+		cur = leaf.hash()
+		if proof.LeafKey == key {
+			value = proof.LeafValue
+		}
+	} else if !proof.LeafKey.IsZero() {
+		return nil, errors.New("proof leaf without value")
+	}
+
+	for i := depth - 1; i >= 0; i-- {
+		h := sha256.New()
+		h.Write([]byte{trieBranchTag})
+		if keyBit(key, i) == 0 {
+			h.Write(cur[:])
+			h.Write(proof.Siblings[i][:])
+		} else {
+			h.Write(proof.Siblings[i][:])
+			h.Write(cur[:])
+		}
+		copy(cur[:], h.Sum(nil))
+	}
+
+	if cur != root {
+		return nil, errors.New("proof root mismatch")
+	}
+	return value, nil
+}
+
+// ProveAccount builds a proof for addr against the given state root.
+// The root may be the current (uncommitted) root or any committed one.
+// Storage slots are not part of the state yet; once they are, their proofs
+// will chain from Account.StorageRoot the same way.
+func (s *StateDB) ProveAccount(root Hash, addr Address) (*AccountProof, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.flush(); err != nil {
+		return nil, err
+	}
+
+	t := s.trie
+	if root != t.Root() {
+		var err error
+		if t, err = NewTrie(s.db, root); err != nil {
+			return nil, err
+		}
+	}
+
+	proof, err := t.Prove(stateKey(addr))
+	if err != nil {
+		return nil, err
+	}
+	return &AccountProof{Address: addr, StateRoot: root, Proof: *proof}, nil
+}
+
+// VerifyAccountProof checks an account proof and returns the proven account.
+// A nil account with a nil error means the address does not exist at root.
+func VerifyAccountProof(root Hash, p *AccountProof) (*Account, error) {
+	if p == nil {
+		return nil, errors.New("nil account proof")
+	}
+	if p.StateRoot != root {
+		return nil, errors.New("proof is for a different state root")
+	}
+
+	value, err := VerifyTrieProof(root, stateKey(p.Address), &p.Proof)
+	if err != nil {
+		return nil, err
+	}
+	if value == nil {
+		return nil, nil
+	}
+
+	acc, err := DecodeAccount(value)
+	if err != nil {
+		return nil, err
+	}
+	if acc.Address != p.Address {
+		return nil, errors.New("proof account address mismatch")
+	}
+	return acc, nil
+}
@@ -0,0 +1,95 @@
+// SPDX-License-Identifier: MIT
+// Dev: KryperAI
+
+package types
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+)
+
+func TestAccountProof(t *testing.T) {
+	s := NewStateDB()
+	addrs := make([]Address, 40)
+	for i := range addrs {
+		addrs[i][0] = byte(i + 1)
+		s.AddBalance(addrs[i], big.NewInt(int64(i+10)))
+	}
+	batch := s.Database().NewBatch()
+	root, err := s.Commit(batch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := batch.Write(); err != nil {
+		t.Fatal(err)
+	}
+	s.MarkStored()
+	// Uncommitted changes must not leak into proofs against root.
+	s.AddBalance(addrs[0], big.NewInt(1))
+
+	for i, a := range addrs {
+		p, err := s.ProveAccount(root, a)
+		if err != nil {
+			t.Fatal(err)
+		}
+		acc, err := VerifyAccountProof(root, p)
+		if err != nil || acc == nil || acc.Balance.Int64() != int64(i+10) {
+			t.Fatalf("account %d: %v, %v", i, acc, err)
+		}
+	}
+
+	var missing Address
+	missing[5] = 9
+	p, err := s.ProveAccount(root, missing)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if acc, err := VerifyAccountProof(root, p); err != nil || acc != nil {
+		t.Fatalf("absence proof: %v, %v", acc, err)
+	}
+
+	p, _ = s.ProveAccount(root, addrs[3])
+	p.Proof.LeafValue[len(p.Proof.LeafValue)-2] ^= 1
+	if _, err := VerifyAccountProof(root, p); err == nil {
+		t.Fatal("tampered proof accepted")
+	}
+}
+
+func TestAddressHashJSON(t *testing.T) {
+	var a Address
+	a[0], a[19] = 0xab, 0x01
+	h := Hash{0xcd}
+
+	out, err := json.Marshal(struct {
+		A Address
+		H Hash
+	}{a, h})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"A":"` + a.String() + `","H":"` + h.String() + `"}`
+	if string(out) != want {
+		t.Fatalf("got %s, want %s", out, want)
+	}
+
+	// Values written before MarshalText existed are byte arrays.
+	legacy, _ := json.Marshal(struct {
+		A [AddressLength]byte
+		H [32]byte
+	}{a, h})
+	var got struct {
+		A Address
+		H Hash
+	}
+	if err := json.Unmarshal(legacy, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.A != a || got.H != h {
+		t.Fatalf("legacy decode: %s %s", got.A, got.H)
+	}
+
+	if err := json.Unmarshal([]byte(`{"A":"0x12"}`), &got); err == nil {
+		t.Fatal("short address accepted")
+	}
+}
@@ -0,0 +1,142 @@
+// SPDX-License-Identifier: MIT
+// Dev: KryperAI
+
+package types
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+)
+
+func TestProposerWeightedByStake(t *testing.T) {
+	var stakes []ValidatorStake
+	for i := 1; i <= 3; i++ {
+		var a Address
+		a[0] = byte(i)
+		stakes = append(stakes, ValidatorStake{Address: a, Stake: big.NewInt(int64(10 * i))})
+	}
+	vs := NewValidatorSet(stakes)
+	counts := map[Address]int{}
+	for h := uint64(1); h <= 6000; h++ {
+		p, ok := vs.Proposer(h, 0)
+		if !ok {
+			t.Fatal("unscheduled height")
+		}
+		if again, _ := vs.Proposer(h, 0); again != p {
+			t.Fatal("schedule not deterministic")
+		}
+		counts[p]++
+	}
+	// Expected 1000/2000/3000; allow generous slack.
+	for i, v := range stakes {
+		want := 1000 * (i + 1)
+		if got := counts[v.Address]; got < want*8/10 || got > want*12/10 {
+			t.Fatalf("validator %d proposed %d times, want about %d", i, got, want)
+		}
+	}
+	if _, ok := NewValidatorSet(nil).Proposer(1, 0); ok {
+		t.Fatal("empty set scheduled a proposer")
+	}
+}
+
+func TestRoundAt(t *testing.T) {
+	cases := []struct {
+		parent, t int64
+		want      uint64
+	}{
+		{100, 90, 0},
+		{100, 101, 0},
+		{100, 100 + ProposerTimeout - 1, 0},
+		{100, 100 + ProposerTimeout, 1},
+		{100, 100 + 3*ProposerTimeout + 5, 3},
+	}
+	for _, c := range cases {
+		if got := RoundAt(c.parent, c.t); got != c.want {
+			t.Errorf("RoundAt(%d, %d) = %d, want %d", c.parent, c.t, got, c.want)
+		}
+	}
+}
+
+// proposerChain returns a chain with three staked validators and one
+// empty block, so height 2 has a scheduled proposer.
+func proposerChain(t *testing.T) *testChain {
+	_, rich := newTestKey(t)
+	var stakes []ValidatorStake
+	for i := 0; i < 3; i++ {
+		_, a := newTestKey(t)
+		stakes = append(stakes, ValidatorStake{Address: a, Stake: big.NewInt(int64(10 * (i + 1)))})
+	}
+	c := newTestChain(t, rich, stakes...)
+	c.mine(t, nil, Address{}, 1)
+	return c
+}
+
+// otherKey returns a staked validator key that is not addr.
+func otherKey(c *testChain, addr Address) *ecdsa.PrivateKey {
+	for _, v := range c.chain.ValidatorSet().Validators() {
+		if v.Address != addr {
+			return testKeys[v.Address]
+		}
+	}
+	return nil
+}
+
+func TestWrongProposerRejected(t *testing.T) {
+	c := proposerChain(t)
+	h, key := c.nextHeader(Address{}, 2)
+	h.StateRoot = c.chain.Head().Header.StateRoot
+
+	SignHeader(h, otherKey(c, h.Proposer))
+	if err := c.chain.AddBlock(NewBlock(h, nil)); err != ErrWrongProposer {
+		t.Fatalf("wrong proposer: %v", err)
+	}
+
+	SignHeader(h, key)
+	h.Timestamp++ // tampered after signing
+	if err := c.chain.AddBlock(NewBlock(h, nil)); err == nil {
+		t.Fatal("tampered header accepted")
+	}
+	h.Timestamp--
+	SignHeader(h, key)
+	if err := c.chain.AddBlock(NewBlock(h, nil)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLaterRoundTakesOver(t *testing.T) {
+	c := proposerChain(t)
+	parent := c.chain.Head().Header
+	height := parent.Height + 1
+
+	// Find a round whose proposer differs from round 0's.
+	p0, _ := c.chain.ProposerAt(height, 0)
+	round := uint64(1)
+	p, _ := c.chain.ProposerAt(height, round)
+	for p == p0 {
+		round++
+		p, _ = c.chain.ProposerAt(height, round)
+	}
+
+	h, _ := c.nextHeader(Address{}, 0)
+	h.StateRoot = parent.StateRoot
+	h.Round = round
+	h.Proposer = p
+
+	// Claiming the round before it opens is rejected.
+	h.Timestamp = parent.Timestamp + int64(round)*ProposerTimeout - 1
+	SignHeader(h, testKeys[p])
+	if err := c.chain.AddBlock(NewBlock(h, nil)); err != ErrFutureRound {
+		t.Fatalf("early round: %v", err)
+	}
+
+	// Once it opens, the round's proposer may seal the height.
+	h.Timestamp = parent.Timestamp + int64(round)*ProposerTimeout
+	SignHeader(h, testKeys[p])
+	if err := c.chain.AddBlock(NewBlock(h, nil)); err != nil {
+		t.Fatal(err)
+	}
+	if c.chain.Head().Header.Proposer != p {
+		t.Fatal("round proposer block not head")
+	}
+}
@@ -0,0 +1,179 @@
+// SPDX-License-Identifier: MIT
+// Dev: KryperAI
+
+package types
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+
+	"krypper-chain/storage"
+)
+
+// Every transaction in a block gets a receipt. A tx whose state change
+// fails (e.g. a transfer above the balance) is still included: its state
+// change is reverted, but the nonce is used and gas is charged, and the
+// receipt has Success false, the failure class in Code and the message in
+// Error. The receipts of a block are stored under its hash and committed
+// to by BlockHeader.ReceiptRoot. Only Code is part of the commitment: the
+// message text may change between releases without forking the chain.
+
+var (
+	ErrReceiptRoot     = errors.New("receipt root mismatch")
+	ErrReceiptNotFound = errors.New("receipt not found")
+)
+
+// ReceiptCode classifies why a tx failed. Values are consensus-critical:
+// never renumber them, only append.
+type ReceiptCode uint8
+
+const (
+	ReceiptOK     ReceiptCode = iota
+	ReceiptFailed             // any failure without a dedicated code
+	ReceiptInsufficientBalance
+	ReceiptInsufficientStake
+	ReceiptInsufficientDelegation
+	ReceiptNotValidator
+	ReceiptInvalidEvidence
+	ReceiptEvidenceExpired
+	ReceiptDuplicateEvidence
+	ReceiptNothingToSlash
+	ReceiptInvalidFreeze
+	ReceiptFreezeSequence
+	ReceiptFreezeUnauthorized
+	ReceiptFreezeNoop
+	ReceiptInvalidBatch
+	ReceiptInvalidMultisigPolicy
+	ReceiptNotMultisig
+	ReceiptMultisigThreshold
+	ReceiptMultisigExists
+)
+
+var receiptCodes = []struct {
+	err  error
+	code ReceiptCode
+}{
+	{ErrInsufficientBalance, ReceiptInsufficientBalance},
+	{ErrInsufficientStake, ReceiptInsufficientStake},
+	{ErrInsufficientDelegation, ReceiptInsufficientDelegation},
+	{ErrNotValidator, ReceiptNotValidator},
+	{ErrInvalidEvidence, ReceiptInvalidEvidence},
+	{ErrEvidenceExpired, ReceiptEvidenceExpired},
+	{ErrDuplicateEvidence, ReceiptDuplicateEvidence},
+	{ErrNothingToSlash, ReceiptNothingToSlash},
+	{ErrInvalidFreeze, ReceiptInvalidFreeze},
+	{ErrFreezeSequence, ReceiptFreezeSequence},
+	{ErrFreezeUnauthorized, ReceiptFreezeUnauthorized},
+	{ErrFreezeNoop, ReceiptFreezeNoop},
+	{ErrInvalidBatch, ReceiptInvalidBatch},
+	{ErrInvalidMultisigPolicy, ReceiptInvalidMultisigPolicy},
+	{ErrNotMultisig, ReceiptNotMultisig},
+	{ErrMultisigThreshold, ReceiptMultisigThreshold},
+	{ErrMultisigExists, ReceiptMultisigExists},
+}
+
+// receiptCodeOf returns the code recorded for a failed state change.
+func receiptCodeOf(err error) ReceiptCode {
+	if err == nil {
+		return ReceiptOK
+	}
+	for _, c := range receiptCodes {
+		if errors.Is(err, c.err) {
+			return c.code
+		}
+	}
+	return ReceiptFailed
+}
+
+// Hash returns the hash a receipt contributes to the receipt root. Error is
+// informational and not hashed.
+func (r *Receipt) Hash() Hash {
+	h := sha256.New()
+	var buf [8]byte
+
+	h.Write(r.TxHash[:])
+	if r.Success {
+		h.Write([]byte{1})
+	} else {
+		h.Write([]byte{0})
+	}
+	h.Write([]byte{byte(r.Code)})
+	binary.BigEndian.PutUint64(buf[:], r.GasUsed)
+	h.Write(buf[:])
+	for _, l := range r.Logs {
+		binary.BigEndian.PutUint64(buf[:], uint64(len(l)))
+		h.Write(buf[:])
+		h.Write(l)
+	}
+
+	var out Hash
+	copy(out[:], h.Sum(nil))
+	return out
+}
+
+// ReceiptRoot returns the Merkle root of receipts (zero if there are none).
+func ReceiptRoot(receipts []*Receipt) Hash {
+	if len(receipts) == 0 {
+		return ZeroHash()
+	}
+	hashes := make([]Hash, len(receipts))
+	for i, r := range receipts {
+		hashes[i] = r.Hash()
+	}
+	return merkleFromHashes(hashes)
+}
+
+// BlockReceipts returns the receipts of the transactions executed in the
+// current block so far.
+func (e *Executor) BlockReceipts() []*Receipt { return e.receipts }
+
+// TxLookup locates a transaction in the chain.
+type TxLookup struct {
+	BlockHash Hash   `json:"blockHash"`
+	Height    uint64 `json:"height"`
+	Index     int    `json:"index"`
+}
+
+// stageReceipts writes the receipts of the block just executed and the
+// lookup entries of its transactions into w.
+func (bc *Blockchain) stageReceipts(w storage.Writer, b *Block) error {
+	h := b.Hash()
+	if err := WriteReceipts(w, h, bc.executor.BlockReceipts()); err != nil {
+		return err
+	}
+	for i, tx := range b.Transactions {
+		if err := WriteTxLookup(w, tx.Hash(), &TxLookup{BlockHash: h, Height: b.Header.Height, Index: i}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetReceipt returns the receipt of a transaction on the canonical chain
+// and where it was included.
+func (bc *Blockchain) GetReceipt(txHash Hash) (*Receipt, *TxLookup, error) {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	l, err := ReadTxLookup(bc.db, txHash)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, nil, ErrReceiptNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	// Entries of blocks reorganised away are left behind.
+	if b := bc.canonicalAt(l.Height); b == nil || b.Hash() != l.BlockHash {
+		return nil, nil, ErrReceiptNotFound
+	}
+
+	receipts, err := ReadReceipts(bc.db, l.BlockHash)
+	if err != nil {
+		return nil, nil, err
+	}
+	if l.Index >= len(receipts) {
+		return nil, nil, ErrReceiptNotFound
+	}
+	return receipts[l.Index], l, nil
+}
@@ -0,0 +1,75 @@
+// SPDX-License-Identifier: MIT
+// Dev: KryperAI
+
+package types
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"testing"
+)
+
+func TestFailedTxGetsReceipt(t *testing.T) {
+	key, rich := newTestKey(t)
+	c := newTestChain(t, rich)
+	to := Address{7}
+	ok := signedTransfer(t, key, 0, to, 5, 1)
+	bad := NewTransferTx(1, 1, to, new(big.Int).Mul(c.state.GetBalance(rich), big.NewInt(2)), big.NewInt(1), TxGas, nil)
+	if err := SignTransaction(bad, key); err != nil {
+		t.Fatal(err)
+	}
+	b := c.mine(t, []*Transaction{ok, bad}, Address{}, 1)
+
+	if c.state.GetNonce(rich) != 2 || c.state.GetBalance(to).Int64() != 5 {
+		t.Fatal("failed tx did not use its nonce or reverted the good one")
+	}
+	r, l, err := c.chain.GetReceipt(bad.Hash())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Success || r.Code != ReceiptInsufficientBalance || r.Error == "" || r.GasUsed != TxGas {
+		t.Fatalf("failed receipt: %+v", r)
+	}
+	if l.Index != 1 || l.BlockHash != b.Hash() {
+		t.Fatal("wrong lookup")
+	}
+	r, _, err = c.chain.GetReceipt(ok.Hash())
+	if err != nil || !r.Success || r.Code != ReceiptOK {
+		t.Fatal("successful receipt", err)
+	}
+	if b.Header.ReceiptRoot.IsZero() {
+		t.Fatal("receipt root not set")
+	}
+}
+
+func TestReceiptHashIgnoresErrorText(t *testing.T) {
+	r := &Receipt{TxHash: Hash{1}, Code: ReceiptInsufficientBalance, GasUsed: TxGas, Error: "insufficient balance"}
+	reworded := *r
+	reworded.Error = "balance too low"
+	if r.Hash() != reworded.Hash() {
+		t.Fatal("error text changes the receipt hash")
+	}
+	recoded := *r
+	recoded.Code = ReceiptFailed
+	if r.Hash() == recoded.Hash() {
+		t.Fatal("code not committed")
+	}
+}
+
+func TestReceiptCodeOf(t *testing.T) {
+	cases := []struct {
+		err  error
+		want ReceiptCode
+	}{
+		{nil, ReceiptOK},
+		{ErrInsufficientStake, ReceiptInsufficientStake},
+		{fmt.Errorf("slash: %w", ErrNothingToSlash), ReceiptNothingToSlash},
+		{errors.New("something else"), ReceiptFailed},
+	}
+	for _, tc := range cases {
+		if got := receiptCodeOf(tc.err); got != tc.want {
+			t.Errorf("receiptCodeOf(%v) = %d, want %d", tc.err, got, tc.want)
+		}
+	}
+}
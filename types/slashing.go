@@ -0,0 +1,271 @@
+// SPDX-License-Identifier: MIT
+// Dev: KryperAI
+
+package types
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"sort"
+)
+
+// EvidenceType identifies the kind of misbehaviour an Evidence proves.
+type EvidenceType uint8
+
+const (
+	EvidenceDuplicateVote   EvidenceType = 0x01 // two votes for different blocks at one height
+	EvidenceDuplicateHeader EvidenceType = 0x02 // two signed headers at one height and round
+)
+
+// Defaults used when the matching ChainConfig field is unset.
+const (
+	DefaultSlashPercent uint64 = 5
+	DefaultJailPeriod   uint64 = 1000
+)
+
+var (
+	ErrInvalidEvidence   = errors.New("invalid evidence")
+	ErrEvidenceExpired   = errors.New("evidence is too old")
+	ErrDuplicateEvidence = errors.New("offence already slashed")
+	ErrNothingToSlash    = errors.New("offender has no stake")
+)
+
+// Evidence proves that a validator signed two conflicting messages at the
+// same height. It is submitted on chain as the Data of a TxTypeEvidence
+// transaction.
+type Evidence struct {
+	Type    EvidenceType   `json:"type"`
+	VoteA   *ValidatorVote `json:"voteA,omitempty"`
+	VoteB   *ValidatorVote `json:"voteB,omitempty"`
+	HeaderA *BlockHeader   `json:"headerA,omitempty"`
+	HeaderB *BlockHeader   `json:"headerB,omitempty"`
+}
+
+// NewDuplicateVoteEvidence wraps two conflicting votes.
+func NewDuplicateVoteEvidence(a, b *ValidatorVote) *Evidence {
+	return &Evidence{Type: EvidenceDuplicateVote, VoteA: a, VoteB: b}
+}
+
+// NewDuplicateHeaderEvidence wraps two conflicting headers.
+func NewDuplicateHeaderEvidence(a, b *BlockHeader) *Evidence {
+	return &Evidence{Type: EvidenceDuplicateHeader, HeaderA: a, HeaderB: b}
+}
+
+// EncodeEvidence returns the canonical encoding used in tx data.
+func EncodeEvidence(ev *Evidence) ([]byte, error) {
+	return json.Marshal(ev)
+}
+
+// DecodeEvidence decodes evidence from tx data.
+func DecodeEvidence(data []byte) (*Evidence, error) {
+	var ev Evidence
+	if err := json.Unmarshal(data, &ev); err != nil {
+		return nil, err
+	}
+	return &ev, nil
+}
+
+// Verify checks both signatures and that the messages really conflict.
+// It returns the offender and the height of the offence.
+func (ev *Evidence) Verify(chainID uint64) (Address, uint64, error) {
+	if ev == nil {
+		return Address{}, 0, ErrInvalidEvidence
+	}
+	switch ev.Type {
+	case EvidenceDuplicateVote:
+		a, b := ev.VoteA, ev.VoteB
+		if a == nil || b == nil {
+			return Address{}, 0, ErrInvalidEvidence
+		}
+		if a.ChainID != chainID || b.ChainID != chainID {
+			return Address{}, 0, errors.New("evidence vote for wrong chain")
+		}
+		if a.Height != b.Height || a.Block == b.Block {
+			return Address{}, 0, errors.New("votes do not conflict")
+		}
+		va, err := VerifyValidatorVote(a)
+		if err != nil {
+			return Address{}, 0, err
+		}
+		vb, err := VerifyValidatorVote(b)
+		if err != nil {
+			return Address{}, 0, err
+		}
+		if va != vb {
+			return Address{}, 0, errors.New("votes from different validators")
+		}
+		return va, a.Height, nil
+
+	case EvidenceDuplicateHeader:
+		a, b := ev.HeaderA, ev.HeaderB
+		if a == nil || b == nil {
+			return Address{}, 0, ErrInvalidEvidence
+		}
+		if a.ChainID != chainID || b.ChainID != chainID {
+			return Address{}, 0, errors.New("evidence header for wrong chain")
+		}
+		// A proposer may propose again at the same height in a later
+		// round, on the same branch or another one.
+		if a.Height != b.Height || a.Round != b.Round || a.HashHeader() == b.HashHeader() {
+			return Address{}, 0, errors.New("headers do not conflict")
+		}
+		if a.Proposer != b.Proposer {
+			return Address{}, 0, errors.New("headers from different proposers")
+		}
+		if err := VerifyHeaderSignature(a); err != nil {
+			return Address{}, 0, err
+		}
+		if err := VerifyHeaderSignature(b); err != nil {
+			return Address{}, 0, err
+		}
+		return a.Proposer, a.Height, nil
+	}
+	return Address{}, 0, errors.New("unknown evidence type")
+}
+
+// offenceKey identifies one offence so it is slashed only once, however
+// many conflicting pairs are submitted for it.
+func offenceKey(t EvidenceType, offender Address, height uint64) Hash {
+	h := sha256.New()
+	var buf [8]byte
+	h.Write([]byte{byte(t)})
+	h.Write(offender[:])
+	binary.BigEndian.PutUint64(buf[:], height)
+	h.Write(buf[:])
+
+	var out Hash
+	copy(out[:], h.Sum(nil))
+	return out
+}
+
+// Slash takes percent of addr's self stake and pending unbonding stake,
+// jails it until jailedUntil and records the offence. It returns the
+// slashed amount, which the caller must credit elsewhere (applyEvidence
+// pays it to the reward pool), so slashing leaves the supply unchanged.
+func (s *StateDB) Slash(addr Address, offence Hash, percent uint64, jailedUntil uint64) (*big.Int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.canSlash(addr, offence); err != nil {
+		return nil, err
+	}
+	acc := s.getOrCreate(addr)
+	i := slashedIndex(acc, offence)
+
+	slashed := calcPct(acc.Stake, percent)
+	acc.Stake = new(big.Int).Sub(acc.Stake, slashed)
+	// Stake already unbonding was bonded at the time of the offence.
+	for j, u := range acc.Unbonding {
+		cut := calcPct(u.Amount, percent)
+		acc.Unbonding[j].Amount = new(big.Int).Sub(u.Amount, cut)
+		slashed.Add(slashed, cut)
+	}
+
+	if jailedUntil > acc.JailedUntil {
+		acc.JailedUntil = jailedUntil
+	}
+	acc.Slashed = append(acc.Slashed, Hash{})
+	copy(acc.Slashed[i+1:], acc.Slashed[i:])
+	acc.Slashed[i] = offence
+	return slashed, nil
+}
+
+// CanSlash reports whether Slash would punish addr for offence: it fails
+// if addr has no stake to slash or the offence is already recorded.
+func (s *StateDB) CanSlash(addr Address, offence Hash) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.canSlash(addr, offence)
+}
+
+// canSlash is CanSlash without locking. Caller must hold s.mu.
+func (s *StateDB) canSlash(addr Address, offence Hash) error {
+	acc := s.getAccount(addr)
+	if acc == nil || (acc.Stake.Sign() == 0 && len(acc.Unbonding) == 0) {
+		return ErrNothingToSlash
+	}
+	if i := slashedIndex(acc, offence); i < len(acc.Slashed) && acc.Slashed[i] == offence {
+		return ErrDuplicateEvidence
+	}
+	return nil
+}
+
+// slashedIndex returns where offence is or belongs in acc's sorted list of
+// slashed offences.
+func slashedIndex(acc *Account, offence Hash) int {
+	return sort.Search(len(acc.Slashed), func(i int) bool {
+		return bytes.Compare(acc.Slashed[i][:], offence[:]) >= 0
+	})
+}
+
+// IsJailed reports whether addr is excluded from validator selection at
+// height.
+func (s *StateDB) IsJailed(addr Address, height uint64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	acc := s.getAccount(addr)
+	return acc != nil && acc.JailedUntil > height
+}
+
+// CheckEvidence checks ev as applyEvidence would for a block at height
+// on top of the current state, without slashing: the signatures and
+// conflict, that the offence is at most height and within one unbonding
+// period of it, and that the offender still has stake and has not been
+// slashed for it. It returns the offender and the key of the offence.
+func (e *Executor) CheckEvidence(ev *Evidence, height uint64) (Address, Hash, error) {
+	offender, at, err := ev.Verify(e.config.ChainID)
+	if err != nil {
+		return Address{}, Hash{}, err
+	}
+
+	// Offences are punishable while the stake bonded at the time can still
+	// be caught, i.e. within one unbonding period.
+	if at > height {
+		return Address{}, Hash{}, errors.New("evidence from the future")
+	}
+	if e.config.UnbondingRelease(at) <= height {
+		return Address{}, Hash{}, ErrEvidenceExpired
+	}
+
+	offence := offenceKey(ev.Type, offender, at)
+	if err := e.state.CanSlash(offender, offence); err != nil {
+		return Address{}, Hash{}, err
+	}
+	return offender, offence, nil
+}
+
+// applyEvidence verifies evidence carried in tx data against the chain
+// config and state and slashes the offender. Slashed stake goes to the
+// reward pool.
+func (e *Executor) applyEvidence(data []byte) error {
+	ev, err := DecodeEvidence(data)
+	if err != nil {
+		return ErrInvalidEvidence
+	}
+	offender, offence, err := e.CheckEvidence(ev, e.current.Height)
+	if err != nil {
+		return err
+	}
+
+	percent := e.config.SlashPercent
+	if percent == 0 {
+		percent = DefaultSlashPercent
+	}
+	jail := e.config.JailPeriod
+	if jail == 0 {
+		jail = DefaultJailPeriod
+	}
+
+	slashed, err := e.state.Slash(offender, offence, percent, e.current.Height+jail)
+	if err != nil {
+		return err
+	}
+	if slashed.Sign() > 0 {
+		return e.state.AddBalance(e.config.RewardPool, slashed)
+	}
+	return nil
+}
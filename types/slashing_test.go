@@ -0,0 +1,163 @@
+// SPDX-License-Identifier: MIT
+// Dev: KryperAI
+
+package types
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+)
+
+func TestDuplicateVoteSlashedAndJailedAtOnce(t *testing.T) {
+	key, rich := newTestKey(t)
+	vk, offender := newTestKey(t)
+	_, honest := newTestKey(t)
+	c := newTestChain(t, rich,
+		ValidatorStake{Address: offender, Stake: big.NewInt(1000)},
+		ValidatorStake{Address: honest, Stake: big.NewInt(1000)})
+	c.exec.config.RewardPool[0] = 0xAA
+
+	va, _ := SignValidatorVote(vk, 1, 1, Hash{1})
+	vb, _ := SignValidatorVote(vk, 1, 1, Hash{2})
+	tx, err := NewEvidenceTx(1, 0, NewDuplicateVoteEvidence(va, vb), big.NewInt(0), 200000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	SignTransaction(tx, key)
+	c.mine(t, []*Transaction{tx}, Address{}, 1)
+
+	if c.state.GetStake(offender).Int64() != 950 || c.state.GetBalance(c.exec.config.RewardPool).Int64() != 50 {
+		t.Fatalf("stake after slash %s", c.state.GetStake(offender))
+	}
+	if !c.state.IsJailed(offender, 2) {
+		t.Fatal("offender not jailed")
+	}
+
+	// Still in the epoch's set, but out of the active set straight away.
+	if !c.chain.ValidatorSetAt(2).Contains(offender) {
+		t.Fatal("epoch set changed mid-epoch")
+	}
+	for round := uint64(0); round < 50; round++ {
+		if p, _ := c.chain.ProposerAt(2, round); p == offender {
+			t.Fatal("jailed validator scheduled to propose")
+		}
+	}
+	vote, _ := SignValidatorVote(vk, 1, 1, c.chain.Head().Hash())
+	if _, err := c.chain.AddVote(vote); err != ErrNotActiveValidator {
+		t.Fatalf("jailed validator vote: %v", err)
+	}
+
+	// The same offence is only slashed once.
+	vc, _ := SignValidatorVote(vk, 1, 1, Hash{3})
+	again, _ := NewEvidenceTx(1, 1, NewDuplicateVoteEvidence(va, vc), big.NewInt(0), 200000)
+	SignTransaction(again, key)
+	c.mine(t, []*Transaction{again}, Address{}, 2)
+	if r, _, _ := c.chain.GetReceipt(again.Hash()); r == nil || r.Success {
+		t.Fatal("offence slashed twice")
+	}
+
+	c.mine(t, nil, Address{}, 3) // epoch end
+	if next := c.chain.ValidatorSetAt(4); next.Contains(offender) || !next.Contains(honest) {
+		t.Fatal("next epoch set")
+	}
+}
+
+func TestEvidenceVerify(t *testing.T) {
+	vk, offender := newTestKey(t)
+	header := func(chainID uint64, ts int64) *BlockHeader {
+		h := &BlockHeader{ChainID: chainID, Height: 1, Timestamp: ts}
+		SignHeader(h, vk)
+		return h
+	}
+	va, _ := SignValidatorVote(vk, 1, 1, Hash{1})
+	vb, _ := SignValidatorVote(vk, 1, 1, Hash{2})
+	otherChain, _ := SignValidatorVote(vk, 2, 1, Hash{2})
+
+	cases := []struct {
+		name string
+		ev   *Evidence
+		ok   bool
+	}{
+		{"conflicting votes", NewDuplicateVoteEvidence(va, vb), true},
+		{"same vote twice", NewDuplicateVoteEvidence(va, va), false},
+		{"vote on other chain", NewDuplicateVoteEvidence(va, otherChain), false},
+		{"conflicting headers", NewDuplicateHeaderEvidence(header(1, 1), header(1, 2)), true},
+		{"same header twice", NewDuplicateHeaderEvidence(header(1, 1), header(1, 1)), false},
+		// Headers signed for another network cannot slash on this one.
+		{"headers on other chain", NewDuplicateHeaderEvidence(header(2, 1), header(2, 2)), false},
+		{"mixed chain headers", NewDuplicateHeaderEvidence(header(1, 1), header(2, 2)), false},
+	}
+	for _, c := range cases {
+		got, height, err := c.ev.Verify(1)
+		if c.ok && (err != nil || got != offender || height != 1) {
+			t.Errorf("%s: %v", c.name, err)
+		}
+		if !c.ok && err == nil {
+			t.Errorf("%s: accepted", c.name)
+		}
+	}
+}
+
+// A proposer whose slot comes round again at the same height proposes
+// again without equivocating.
+func TestLaterRoundProposalNotSlashable(t *testing.T) {
+	vk, _ := newTestKey(t)
+	first := &BlockHeader{ChainID: 1, Height: 5, Timestamp: 100}
+	SignHeader(first, vk)
+	again := &BlockHeader{ChainID: 1, Height: 5, Round: 1, Timestamp: 100 + ProposerTimeout}
+	SignHeader(again, vk)
+	if _, _, err := NewDuplicateHeaderEvidence(first, again).Verify(1); err == nil {
+		t.Fatal("later-round proposal slashable")
+	}
+
+	// Two proposals in the same round still are.
+	twin := &BlockHeader{ChainID: 1, Height: 5, Round: 1, Timestamp: 101 + ProposerTimeout}
+	SignHeader(twin, vk)
+	if _, _, err := NewDuplicateHeaderEvidence(again, twin).Verify(1); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCheckEvidence(t *testing.T) {
+	_, rich := newTestKey(t)
+	vk, offender := newTestKey(t)
+	sk, _ := newTestKey(t) // never staked
+	c := newTestChain(t, rich, ValidatorStake{Address: offender, Stake: big.NewInt(1000)})
+	c.exec.config.UnbondingPeriod = 5
+
+	pair := func(k *ecdsa.PrivateKey, height uint64) *Evidence {
+		a, _ := SignValidatorVote(k, 1, height, Hash{1})
+		b, _ := SignValidatorVote(k, 1, height, Hash{2})
+		return NewDuplicateVoteEvidence(a, b)
+	}
+	cases := []struct {
+		name   string
+		ev     *Evidence
+		height uint64
+		ok     bool
+	}{
+		{"punishable", pair(vk, 2), 3, true},
+		{"last height before release", pair(vk, 2), 6, true},
+		{"expired", pair(vk, 2), 7, false},
+		{"from the future", pair(vk, 4), 3, false},
+		{"offender without stake", pair(sk, 2), 3, false},
+	}
+	for _, tc := range cases {
+		if _, _, err := c.exec.CheckEvidence(tc.ev, tc.height); (err == nil) != tc.ok {
+			t.Errorf("%s: %v", tc.name, err)
+		}
+	}
+
+	// An offence already recorded in state is refused.
+	_, offence, err := c.exec.CheckEvidence(pair(vk, 2), 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.state.Slash(offender, offence, 5, 10); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := c.exec.CheckEvidence(pair(vk, 2), 3); err != ErrDuplicateEvidence {
+		t.Fatalf("slashed offence: %v", err)
+	}
+}
@@ -0,0 +1,216 @@
+// SPDX-License-Identifier: MIT
+// Dev: KryperAI
+
+package types
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"sort"
+)
+
+// Delegation is stake an account has delegated to a validator.
+type Delegation struct {
+	Validator Address  `json:"validator"`
+	Amount    *big.Int `json:"amount"`
+}
+
+// UnbondingEntry is stake leaving a validator; it is returned to the
+// account balance once the chain reaches ReleaseHeight.
+type UnbondingEntry struct {
+	Amount        *big.Int `json:"amount"`
+	ReleaseHeight uint64   `json:"releaseHeight"`
+}
+
+// DefaultUnbondingPeriod is used when ChainConfig.UnbondingPeriod is unset.
+const DefaultUnbondingPeriod uint64 = 1000
+
+var (
+	ErrInsufficientStake      = errors.New("insufficient stake")
+	ErrInsufficientDelegation = errors.New("insufficient delegation")
+	ErrNotValidator           = errors.New("target is not a validator")
+)
+
+// AddStake moves amount from the account balance into its own stake.
+func (s *StateDB) AddStake(addr Address, amount *big.Int) error {
+	if amount == nil || amount.Sign() <= 0 {
+		return errors.New("stake amount must be positive")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	acc := s.getOrCreate(addr)
+	if err := acc.SubBalance(amount); err != nil {
+		return err
+	}
+	acc.Stake = new(big.Int).Add(acc.Stake, amount)
+	return nil
+}
+
+// Unstake removes amount from the account's own stake into unbonding,
+// released at releaseHeight.
+func (s *StateDB) Unstake(addr Address, amount *big.Int, releaseHeight uint64) error {
+	if amount == nil || amount.Sign() <= 0 {
+		return errors.New("unstake amount must be positive")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	acc := s.getOrCreate(addr)
+	if acc.Stake.Cmp(amount) < 0 {
+		return ErrInsufficientStake
+	}
+	acc.Stake = new(big.Int).Sub(acc.Stake, amount)
+	acc.Unbonding = append(acc.Unbonding, UnbondingEntry{Amount: new(big.Int).Set(amount), ReleaseHeight: releaseHeight})
+	return nil
+}
+
+// Delegate moves amount from the delegator's balance to validator.
+// The validator must have a positive self stake.
+func (s *StateDB) Delegate(delegator, validator Address, amount *big.Int) error {
+	if amount == nil || amount.Sign() <= 0 {
+		return errors.New("delegation amount must be positive")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if v := s.getAccount(validator); v == nil || v.Stake.Sign() <= 0 {
+		return ErrNotValidator
+	}
+
+	d := s.getOrCreate(delegator)
+	if err := d.SubBalance(amount); err != nil {
+		return err
+	}
+	setDelegation(d, validator, new(big.Int).Add(delegationOf(d, validator), amount))
+
+	v := s.getOrCreate(validator)
+	v.DelegatedStake = new(big.Int).Add(v.DelegatedStake, amount)
+	return nil
+}
+
+// Undelegate withdraws amount of the delegator's stake from validator into
+// unbonding, released at releaseHeight.
+func (s *StateDB) Undelegate(delegator, validator Address, amount *big.Int, releaseHeight uint64) error {
+	if amount == nil || amount.Sign() <= 0 {
+		return errors.New("undelegation amount must be positive")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	d := s.getOrCreate(delegator)
+	cur := delegationOf(d, validator)
+	if cur.Cmp(amount) < 0 {
+		return ErrInsufficientDelegation
+	}
+	setDelegation(d, validator, new(big.Int).Sub(cur, amount))
+	d.Unbonding = append(d.Unbonding, UnbondingEntry{Amount: new(big.Int).Set(amount), ReleaseHeight: releaseHeight})
+
+	v := s.getOrCreate(validator)
+	v.DelegatedStake = new(big.Int).Sub(v.DelegatedStake, amount)
+	if v.DelegatedStake.Sign() < 0 {
+		v.DelegatedStake = big.NewInt(0)
+	}
+	return nil
+}
+
+// ReleaseUnbonded credits every unbonding entry of addr that has matured
+// at height back to its balance and returns the released amount.
+func (s *StateDB) ReleaseUnbonded(addr Address, height uint64) *big.Int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	released := big.NewInt(0)
+	acc := s.getAccount(addr)
+	if acc == nil || len(acc.Unbonding) == 0 {
+		return released
+	}
+	matured := false
+	for _, u := range acc.Unbonding {
+		if u.ReleaseHeight <= height {
+			matured = true
+			break
+		}
+	}
+	if !matured {
+		return released
+	}
+
+	acc = s.getOrCreate(addr)
+	remaining := acc.Unbonding[:0:0]
+	for _, u := range acc.Unbonding {
+		if u.ReleaseHeight <= height {
+			released.Add(released, u.Amount)
+			continue
+		}
+		remaining = append(remaining, u)
+	}
+	acc.Unbonding = remaining
+	acc.Balance = new(big.Int).Add(acc.Balance, released)
+	return released
+}
+
+// GetDelegatedStake returns the stake delegated to a validator.
+func (s *StateDB) GetDelegatedStake(addr Address) *big.Int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	acc := s.getAccount(addr)
+	if acc == nil {
+		return big.NewInt(0)
+	}
+	return copyBig(acc.DelegatedStake)
+}
+
+// GetDelegations returns the delegations made by an account.
+func (s *StateDB) GetDelegations(addr Address) []Delegation {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	acc := s.getAccount(addr)
+	if acc == nil {
+		return nil
+	}
+	return acc.Copy().Delegations
+}
+
+// GetUnbonding returns the pending unbonding entries of an account.
+func (s *StateDB) GetUnbonding(addr Address) []UnbondingEntry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	acc := s.getAccount(addr)
+	if acc == nil {
+		return nil
+	}
+	return acc.Copy().Unbonding
+}
+
+// delegationOf returns the amount acc has delegated to validator.
+func delegationOf(acc *Account, validator Address) *big.Int {
+	for _, d := range acc.Delegations {
+		if d.Validator == validator {
+			return copyBig(d.Amount)
+		}
+	}
+	return big.NewInt(0)
+}
+
+// setDelegation stores a delegation amount, keeping the list sorted by
+// validator and dropping zero entries so the encoding stays canonical.
+func setDelegation(acc *Account, validator Address, amount *big.Int) {
+	out := make([]Delegation, 0, len(acc.Delegations)+1)
+	for _, d := range acc.Delegations {
+		if d.Validator != validator {
+			out = append(out, d)
+		}
+	}
+	if amount.Sign() > 0 {
+		out = append(out, Delegation{Validator: validator, Amount: amount})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return bytes.Compare(out[i].Validator[:], out[j].Validator[:]) < 0
+	})
+	if len(out) == 0 {
+		out = nil
+	}
+	acc.Delegations = out
+}
@@ -0,0 +1,99 @@
+// SPDX-License-Identifier: MIT
+// Dev: KryperAI
+
+package types
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+)
+
+func TestStakeDelegateAndUnbond(t *testing.T) {
+	key, rich := newTestKey(t)
+	c := newTestChain(t, rich)
+	c.exec.config.UnbondingPeriod = 3
+	c.exec.config.MinStake = big.NewInt(100)
+	dkey, delegator := newTestKey(t)
+
+	sign := func(k *ecdsa.PrivateKey, tx *Transaction) *Transaction {
+		if err := SignTransaction(tx, k); err != nil {
+			t.Fatal(err)
+		}
+		return tx
+	}
+
+	fund := sign(key, NewTransferTx(1, 0, delegator, big.NewInt(1000000), big.NewInt(1), TxGas, nil))
+	stake := sign(key, NewStakingTx(1, TxTypeStake, 1, Address{}, big.NewInt(150), big.NewInt(1), 50000))
+	c.mine(t, []*Transaction{fund, stake}, Address{}, 1)
+	del := sign(dkey, NewStakingTx(1, TxTypeDelegate, 0, rich, big.NewInt(50), big.NewInt(1), 50000))
+	c.mine(t, []*Transaction{del}, Address{}, 2)
+	c.mine(t, nil, Address{}, 3) // epoch end
+
+	vs := c.chain.ValidatorSetAt(4)
+	if !vs.Contains(rich) || vs.StakeOf(rich).Int64() != 200 {
+		t.Fatalf("weight %s, want self stake plus delegation", vs.StakeOf(rich))
+	}
+	if c.state.GetDelegatedStake(rich).Int64() != 50 {
+		t.Fatal("delegated stake")
+	}
+
+	// Undelegated at height 4, released at 4+3.
+	undel := sign(dkey, NewStakingTx(1, TxTypeUndelegate, 1, rich, big.NewInt(50), big.NewInt(1), 50000))
+	c.mine(t, []*Transaction{undel}, Address{}, 4)
+	if u := c.state.GetUnbonding(delegator); len(u) != 1 || u[0].Amount.Int64() != 50 {
+		t.Fatalf("unbonding %v", u)
+	}
+	bal := c.state.GetBalance(delegator)
+	c.mine(t, nil, Address{}, 5)
+	c.mine(t, nil, Address{}, 6)
+	c.mine(t, nil, Address{}, 7) // epoch end
+	if c.chain.ValidatorSetAt(8).StakeOf(rich).Int64() != 150 {
+		t.Fatal("undelegated stake still counted")
+	}
+	if c.state.GetBalance(delegator).Cmp(bal) != 0 {
+		t.Fatal("released before the delegator's next tx")
+	}
+
+	// Unbonded funds return on the delegator's next tx.
+	tr := sign(dkey, NewTransferTx(1, 2, rich, big.NewInt(1), big.NewInt(1), TxGas, nil))
+	c.mine(t, []*Transaction{tr}, Address{}, 8)
+	want := new(big.Int).Sub(bal, big.NewInt(int64(TxGas)+1))
+	want.Add(want, big.NewInt(50))
+	if c.state.GetBalance(delegator).Cmp(want) != 0 || len(c.state.GetUnbonding(delegator)) != 0 {
+		t.Fatalf("balance %s, want %s", c.state.GetBalance(delegator), want)
+	}
+}
+
+func TestStakeBelowMinimumIsNotSelected(t *testing.T) {
+	key, rich := newTestKey(t)
+	c := newTestChain(t, rich)
+	c.exec.config.MinStake = big.NewInt(100)
+	tx := NewStakingTx(1, TxTypeStake, 0, Address{}, big.NewInt(99), big.NewInt(1), 50000)
+	SignTransaction(tx, key)
+	c.mine(t, []*Transaction{tx}, Address{}, 1)
+	c.mine(t, nil, Address{}, 2)
+	c.mine(t, nil, Address{}, 3)
+	if c.state.GetStake(rich).Int64() != 99 {
+		t.Fatal("stake not held")
+	}
+	if c.chain.ValidatorSetAt(4).Contains(rich) {
+		t.Fatal("staker below min_stake selected")
+	}
+}
+
+func TestUnstakeMoreThanStaked(t *testing.T) {
+	key, rich := newTestKey(t)
+	c := newTestChain(t, rich)
+	stake := NewStakingTx(1, TxTypeStake, 0, Address{}, big.NewInt(10), big.NewInt(1), 50000)
+	unstake := NewStakingTx(1, TxTypeUnstake, 1, Address{}, big.NewInt(11), big.NewInt(1), 50000)
+	SignTransaction(stake, key)
+	SignTransaction(unstake, key)
+	c.mine(t, []*Transaction{stake, unstake}, Address{}, 1)
+	if r, _, _ := c.chain.GetReceipt(unstake.Hash()); r == nil || r.Success {
+		t.Fatal("unstaking more than staked succeeded")
+	}
+	if c.state.GetStake(rich).Int64() != 10 {
+		t.Fatal("stake changed")
+	}
+}
@@ -4,141 +4,342 @@
 package types
 
 import (
-        "crypto/sha256"
-        "math/big"
+	"crypto/sha256"
+	"errors"
+	"math/big"
+	"sync"
+
+	"krypper-chain/storage"
 )
 
 // StateDB is the chain global state container.
-// In final implementation this should connect to a persistent DB (LevelDB/MPT),
-// but for genesis & bring-up it works fully in-memory.
+// Accounts live in an authenticated Trie keyed by sha256(address); touched
+// accounts are cached in memory and folded into the trie lazily, so the root
+// only rehashes the paths of accounts changed since the last flush.
+// Snapshots are journal based: only accounts mutated after a snapshot are
+// recorded, so snapshot and revert cost scales with touched accounts.
 type StateDB struct {
-        accounts  map[Address]*Account
-        snapshots []map[Address]*Account
+	mu       sync.Mutex
+	db       storage.Database
+	trie     *Trie
+	accounts map[Address]*Account // read since the last commit; nil = removed by a revert
+	dirty    map[Address]struct{}
+	journal  *journal
 }
 
+// NewStateDB creates an empty state backed by an in-memory database.
 func NewStateDB() *StateDB {
-        return &StateDB{
-                accounts:  make(map[Address]*Account),
-                snapshots: make([]map[Address]*Account, 0),
-        }
+	s, _ := NewStateDBAt(storage.NewMemoryDB(), ZeroHash())
+	return s
+}
+
+// NewStateDBFromDB opens the state last committed to db (empty if none).
+func NewStateDBFromDB(db storage.Database) (*StateDB, error) {
+	root, err := ReadStateRoot(db)
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		return nil, err
+	}
+	return NewStateDBAt(db, root)
+}
+
+// NewStateDBAt opens the state with the given trie root.
+func NewStateDBAt(db storage.Database, root Hash) (*StateDB, error) {
+	t, err := NewTrie(db, root)
+	if err != nil {
+		return nil, err
+	}
+	return &StateDB{
+		db:       db,
+		trie:     t,
+		accounts: make(map[Address]*Account),
+		dirty:    make(map[Address]struct{}),
+		journal:  newJournal(),
+	}, nil
+}
+
+// Reset discards all uncommitted changes and snapshots and reopens the
+// state at a committed root. Used to rewind state during reorgs.
+func (s *StateDB) Reset(root Hash) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, err := NewTrie(s.db, root)
+	if err != nil {
+		return err
+	}
+	s.trie = t
+	s.accounts = make(map[Address]*Account)
+	s.dirty = make(map[Address]struct{})
+	s.journal = newJournal()
+	return nil
+}
+
+// Database returns the backing key-value store.
+func (s *StateDB) Database() storage.Database {
+	return s.db
+}
+
+// stateKey is the trie key of an address.
+func stateKey(addr Address) Hash {
+	return Hash(sha256.Sum256(addr[:]))
 }
 
 // GetAccount returns an existing account or nil.
 func (s *StateDB) GetAccount(addr Address) *Account {
-        return s.accounts[addr]
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.getAccount(addr)
+}
+
+func (s *StateDB) getAccount(addr Address) *Account {
+	if acc, ok := s.accounts[addr]; ok {
+		return acc
+	}
+	data, err := s.trie.Get(stateKey(addr))
+	if err != nil || data == nil {
+		return nil
+	}
+	acc, err := DecodeAccount(data)
+	if err != nil {
+		return nil
+	}
+	s.accounts[addr] = acc
+	return acc
+}
+
+// getOrCreate returns the account for addr, creating it if needed, records
+// its prior value in the journal and marks it dirty for the next trie flush.
+// Every mutation must go through here.
+func (s *StateDB) getOrCreate(addr Address) *Account {
+	acc := s.getAccount(addr)
+	if s.journal.active() {
+		s.journal.append(addr, acc.Copy())
+	}
+	if acc == nil {
+		acc = NewAccount(addr)
+		s.accounts[addr] = acc
+	}
+	s.dirty[addr] = struct{}{}
+	return acc
 }
 
 // CreateAccount ensures a new account exists.
 func (s *StateDB) CreateAccount(addr Address) error {
-        if _, ok := s.accounts[addr]; ok {
-                return nil
-        }
-        s.accounts[addr] = NewAccount(addr)
-        return nil
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.getAccount(addr) != nil {
+		return nil
+	}
+	s.getOrCreate(addr)
+	return nil
 }
 
 // GetBalance returns the balance of an account.
 func (s *StateDB) GetBalance(addr Address) *big.Int {
-        acc := s.GetAccount(addr)
-        if acc == nil {
-                return big.NewInt(0)
-        }
-        return new(big.Int).Set(acc.Balance)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	acc := s.getAccount(addr)
+	if acc == nil {
+		return big.NewInt(0)
+	}
+	return new(big.Int).Set(acc.Balance)
 }
 
 // GetNonce returns the nonce of an account.
 func (s *StateDB) GetNonce(addr Address) uint64 {
-        acc := s.GetAccount(addr)
-        if acc == nil {
-                return 0
-        }
-        return acc.Nonce
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	acc := s.getAccount(addr)
+	if acc == nil {
+		return 0
+	}
+	return acc.Nonce
 }
 
 // AddBalance adds amount to an account's balance.
 func (s *StateDB) AddBalance(addr Address, amount *big.Int) error {
-        if s.GetAccount(addr) == nil {
-                if err := s.CreateAccount(addr); err != nil {
-                        return err
-                }
-        }
-        return s.accounts[addr].AddBalance(amount)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.getOrCreate(addr).AddBalance(amount)
 }
 
 // SubBalance subtracts amount from an account's balance.
 func (s *StateDB) SubBalance(addr Address, amount *big.Int) error {
-        if s.GetAccount(addr) == nil {
-                if err := s.CreateAccount(addr); err != nil {
-                        return err
-                }
-        }
-        return s.accounts[addr].SubBalance(amount)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.getOrCreate(addr).SubBalance(amount)
 }
 
 // IncrementNonce increments an account's nonce.
 func (s *StateDB) IncrementNonce(addr Address) error {
-        if s.GetAccount(addr) == nil {
-                if err := s.CreateAccount(addr); err != nil {
-                        return err
-                }
-        }
-        return s.accounts[addr].IncrementNonce()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.getOrCreate(addr).IncrementNonce()
 }
 
 // Mint increases account balance. Used by genesis/initRewards.
 func (s *StateDB) Mint(addr Address, amount *big.Int) error {
-        return s.AddBalance(addr, amount)
+	return s.AddBalance(addr, amount)
 }
 
-// SetStake sets validator stake (for compatibility with core/genesis.go)
+// SetStake sets the validator stake of an account.
 func (s *StateDB) SetStake(addr Address, stake *big.Int) error {
-        if s.GetAccount(addr) == nil {
-                if err := s.CreateAccount(addr); err != nil {
-                        return err
-                }
-        }
-        // Note: current Account structure doesn't have Stake field in account.go
-        // This is a compatibility stub for the core package
-        return nil
+	if stake == nil || stake.Sign() < 0 {
+		return errors.New("stake must be non-negative")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.getOrCreate(addr).Stake = new(big.Int).Set(stake)
+	return nil
 }
 
-// StateRoot computes the state root hash from all accounts.
+// GetStake returns the validator stake of an account.
+func (s *StateDB) GetStake(addr Address) *big.Int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	acc := s.getAccount(addr)
+	if acc == nil || acc.Stake == nil {
+		return big.NewInt(0)
+	}
+	return new(big.Int).Set(acc.Stake)
+}
+
+// Stakers returns every account with a positive self stake that is not
+// jailed at height, weighted by self stake plus delegations. It walks the
+// whole trie, so it is meant for epoch boundaries rather than per-block use.
+func (s *StateDB) Stakers(height uint64) ([]ValidatorStake, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.flush(); err != nil {
+		return nil, err
+	}
+
+	var (
+		out    []ValidatorStake
+		decErr error
+	)
+	err := s.trie.Iterate(func(_ Hash, value []byte) bool {
+		acc, err := DecodeAccount(value)
+		if err != nil {
+			decErr = err
+			return false
+		}
+		if acc.Stake.Sign() > 0 && acc.JailedUntil <= height {
+			out = append(out, ValidatorStake{
+				Address:   acc.Address,
+				Stake:     new(big.Int).Add(acc.Stake, acc.DelegatedStake),
+				SelfStake: acc.Stake,
+			})
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, decErr
+}
+
+// flush folds all dirty accounts into the trie.
+func (s *StateDB) flush() error {
+	for addr := range s.dirty {
+		key := stateKey(addr)
+		acc, ok := s.accounts[addr]
+		if !ok || acc == nil {
+			if err := s.trie.Delete(key); err != nil {
+				return err
+			}
+			continue
+		}
+		data, err := EncodeAccount(acc)
+		if err != nil {
+			return err
+		}
+		if err := s.trie.Update(key, data); err != nil {
+			return err
+		}
+	}
+	s.dirty = make(map[Address]struct{})
+	return nil
+}
+
+// StateRoot returns the root hash of the state trie.
 func (s *StateDB) StateRoot() Hash {
-        h := sha256.New()
-        for _, acc := range s.accounts {
-                if acc != nil {
-                        accHash := acc.Hash()
-                        h.Write(accHash[:])
-                }
-        }
-        var out Hash
-        copy(out[:], h.Sum(nil))
-        return out
-}
-
-// Snapshot creates a snapshot of the current state.
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.flush(); err != nil {
+		return ZeroHash()
+	}
+	return s.trie.Root()
+}
+
+// Commit writes new trie nodes and the resulting root into w. The account
+// cache is dropped afterwards, so it only ever holds the accounts touched
+// since the last commit; later reads decode them from the trie again.
+// Once w has been written the caller must call MarkStored, or
+// DiscardPending if the write failed (see Trie.Commit).
+func (s *StateDB) Commit(w storage.Writer) (Hash, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.flush(); err != nil {
+		return Hash{}, err
+	}
+	root, err := s.trie.Commit(w)
+	if err != nil {
+		return Hash{}, err
+	}
+	if err := WriteStateRoot(w, root); err != nil {
+		return Hash{}, err
+	}
+	s.accounts = make(map[Address]*Account)
+	return root, nil
+}
+
+// MarkStored records the trie nodes of earlier commits as persisted.
+func (s *StateDB) MarkStored() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.trie.MarkStored()
+}
+
+// DiscardPending makes the next commit write the trie nodes of earlier,
+// unwritten commits again.
+func (s *StateDB) DiscardPending() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.trie.DiscardPending()
+}
+
+// Snapshot opens a nested snapshot and returns its ID.
 func (s *StateDB) Snapshot() int {
-        snap := make(map[Address]*Account)
-        for addr, acc := range s.accounts {
-                snap[addr] = acc.Copy()
-        }
-        s.snapshots = append(s.snapshots, snap)
-        return len(s.snapshots) - 1
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.journal.snapshot()
 }
 
-// RevertToSnapshot reverts the state to a previous snapshot.
+// RevertToSnapshot undoes every change made since snapID was taken and
+// drops that snapshot along with any nested inside it.
 func (s *StateDB) RevertToSnapshot(snapID int) {
-        if snapID < 0 || snapID >= len(s.snapshots) {
-                return
-        }
-        s.accounts = s.snapshots[snapID]
-        s.snapshots = s.snapshots[:snapID]
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.journal.valid(snapID) {
+		return
+	}
+	s.journal.revert(snapID, func(e journalEntry) {
+		// Reverted accounts are re-flushed so the trie drops any
+		// intermediate value folded in by StateRoot.
+		s.accounts[e.addr] = e.prev
+		s.dirty[e.addr] = struct{}{}
+	})
 }
 
-// CommitSnapshot removes a snapshot after successful execution.
+// CommitSnapshot keeps the changes made since snapID and drops that
+// snapshot along with any nested inside it. Enclosing snapshots can still
+// revert them.
 func (s *StateDB) CommitSnapshot(snapID int) {
-        if snapID < 0 || snapID >= len(s.snapshots) {
-                return
-        }
-        s.snapshots = s.snapshots[:snapID]
-}
\ No newline at end of file
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.journal.valid(snapID) {
+		return
+	}
+	s.journal.discard(snapID)
+}
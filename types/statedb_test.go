@@ -0,0 +1,191 @@
+// SPDX-License-Identifier: MIT
+// Dev: KryperAI
+
+package types
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"testing"
+
+	"krypper-chain/storage"
+)
+
+func testAddress(i int) Address {
+	var a Address
+	a[0], a[1], a[2] = byte(i), byte(i>>8), byte(i>>16)
+	a[19] = 1
+	return a
+}
+
+func TestSnapshotRevert(t *testing.T) {
+	s := NewStateDB()
+	a, b := testAddress(1), testAddress(2)
+	s.AddBalance(a, big.NewInt(5))
+	root := s.StateRoot()
+
+	outer := s.Snapshot()
+	s.AddBalance(a, big.NewInt(5))
+	inner := s.Snapshot()
+	s.AddBalance(b, big.NewInt(7))
+	_ = s.StateRoot() // fold intermediate values into the trie
+	s.RevertToSnapshot(inner)
+	if s.GetAccount(b) != nil || s.GetBalance(a).Int64() != 10 {
+		t.Fatal("inner revert")
+	}
+	s.RevertToSnapshot(outer)
+	if s.GetBalance(a).Int64() != 5 || s.StateRoot() != root {
+		t.Fatal("outer revert")
+	}
+}
+
+func TestCommitSnapshotKeepsChanges(t *testing.T) {
+	s := NewStateDB()
+	a := testAddress(1)
+	outer := s.Snapshot()
+	inner := s.Snapshot()
+	s.AddBalance(a, big.NewInt(3))
+	s.CommitSnapshot(inner)
+	if s.GetBalance(a).Int64() != 3 {
+		t.Fatal("commit dropped change")
+	}
+	// The enclosing snapshot can still undo it.
+	s.RevertToSnapshot(outer)
+	if s.GetAccount(a) != nil {
+		t.Fatal("enclosing revert")
+	}
+}
+
+func TestCommitTrimsAccountCache(t *testing.T) {
+	s := NewStateDB()
+	for i := 0; i < 100; i++ {
+		s.AddBalance(testAddress(i), big.NewInt(int64(i+1)))
+	}
+	snap := s.Snapshot()
+	s.AddBalance(testAddress(200), big.NewInt(1))
+
+	batch := s.Database().NewBatch()
+	root, err := s.Commit(batch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := batch.Write(); err != nil {
+		t.Fatal(err)
+	}
+	s.MarkStored()
+	if len(s.accounts) != 0 {
+		t.Fatalf("%d accounts cached after commit", len(s.accounts))
+	}
+	if s.GetBalance(testAddress(42)).Int64() != 43 || s.StateRoot() != root {
+		t.Fatal("state lost after trim")
+	}
+
+	// A snapshot opened before the commit still reverts correctly.
+	s.RevertToSnapshot(snap)
+	if s.GetAccount(testAddress(200)) != nil || s.GetBalance(testAddress(42)).Int64() != 43 {
+		t.Fatal("revert after commit")
+	}
+}
+
+// benchStateSizes are the state sizes benchmarks run against. Each
+// iteration touches benchTouched accounts whatever the size, so the cost
+// should stay flat across sizes.
+var benchStateSizes = []int{1000, 10000, 100000}
+
+const benchTouched = 50
+
+// newBenchState returns a state holding n committed accounts.
+func newBenchState(b *testing.B, n int) *StateDB {
+	s := NewStateDB()
+	for i := 0; i < n; i++ {
+		s.AddBalance(testAddress(i), big.NewInt(100))
+	}
+	batch := s.Database().NewBatch()
+	if _, err := s.Commit(batch); err != nil {
+		b.Fatal(err)
+	}
+	if err := batch.Write(); err != nil {
+		b.Fatal(err)
+	}
+	s.MarkStored()
+	return s
+}
+
+// BenchmarkSnapshotRevert runs a block-sized batch of per-tx snapshots on
+// top of states of growing size and reverts the block.
+func BenchmarkSnapshotRevert(b *testing.B) {
+	for _, size := range benchStateSizes {
+		b.Run(fmt.Sprintf("accounts=%d", size), func(b *testing.B) {
+			s := newBenchState(b, size)
+			b.ResetTimer()
+			for n := 0; n < b.N; n++ {
+				block := s.Snapshot()
+				for i := 0; i < benchTouched; i++ {
+					tx := s.Snapshot()
+					s.AddBalance(testAddress(i), big.NewInt(1))
+					s.CommitSnapshot(tx)
+				}
+				s.StateRoot()
+				s.RevertToSnapshot(block)
+			}
+		})
+	}
+}
+
+// BenchmarkCommit measures committing a block of touched accounts on top
+// of states of growing size.
+func BenchmarkCommit(b *testing.B) {
+	for _, size := range benchStateSizes {
+		b.Run(fmt.Sprintf("accounts=%d", size), func(b *testing.B) {
+			s := newBenchState(b, size)
+			b.ResetTimer()
+			for n := 0; n < b.N; n++ {
+				for i := 0; i < benchTouched; i++ {
+					s.AddBalance(testAddress((n*benchTouched+i)%size), big.NewInt(1))
+				}
+				batch := s.Database().NewBatch()
+				if _, err := s.Commit(batch); err != nil {
+					b.Fatal(err)
+				}
+				if err := batch.Write(); err != nil {
+					b.Fatal(err)
+				}
+				s.MarkStored()
+			}
+		})
+	}
+}
+
+// BenchmarkAddBlock imports blocks of transfers to a fixed set of
+// recipients on top of genesis states of growing size. Building and
+// signing the blocks is not timed. Selecting a validator set walks every
+// staker by design, so the chain runs in a single epoch to measure the
+// per-block cost alone.
+func BenchmarkAddBlock(b *testing.B) {
+	for _, size := range benchStateSizes {
+		b.Run(fmt.Sprintf("accounts=%d", size), func(b *testing.B) {
+			key, rich := newTestKey(b)
+			c := newTestChainAlloc(b, storage.NewMemoryDB(), func(s *StateDB) {
+				s.Mint(rich, new(big.Int).Mul(big.NewInt(1000), big.NewInt(1e18)))
+				for i := 0; i < size; i++ {
+					s.AddBalance(testAddress(i), big.NewInt(100))
+				}
+			})
+			c.exec.config.EpochLength = math.MaxUint64
+			b.ResetTimer()
+			for n := 0; n < b.N; n++ {
+				b.StopTimer()
+				txs := make([]*Transaction, benchTouched)
+				for i := range txs {
+					txs[i] = signedTransfer(b, key, uint64(n*benchTouched+i), testAddress(i), 1, 1)
+				}
+				blk := c.build(b, txs, Address{}, int64(n+1))
+				b.StartTimer()
+				if err := c.chain.AddBlock(blk); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -14,7 +14,23 @@ import (
 type TxType uint8
 
 const (
-        TxTypeTransfer TxType = 0x01
+        TxTypeTransfer       TxType = 0x01
+        TxTypeStake          TxType = 0x02 // Value: balance -> own stake
+        TxTypeUnstake        TxType = 0x03 // Value: own stake -> unbonding
+        TxTypeDelegate       TxType = 0x04 // Value: balance -> stake delegated to To
+        TxTypeUndelegate     TxType = 0x05 // Value: delegation to To -> unbonding
+        TxTypeEvidence       TxType = 0x06 // Data: Evidence of a double-signing validator
+        TxTypeFreeze         TxType = 0x07 // Data: approved FreezeAction freezing or unfreezing an account
+        TxTypeBatch          TxType = 0x08 // Data: BatchTransfer list; Value: their total
+        TxTypeMultisigCreate TxType = 0x09 // Data: MultisigPolicy of a new multisig account; Value: its funding
+        TxTypeMultisigUpdate TxType = 0x0A // Data: new MultisigPolicy of the sending multisig account
+)
+
+// Replay protection errors, shared by the mempool, the executor and RPC.
+var (
+        ErrNonceTooLow  = errors.New("nonce too low")
+        ErrNonceTooHigh = errors.New("nonce too high")
+        ErrWrongChain   = errors.New("wrong chain id")
 )
 
 type Signature struct {
@@ -30,14 +46,53 @@ type Transaction struct {
         To        Address   `json:"to"`
         Value     *big.Int  `json:"value"`
         GasPrice  *big.Int  `json:"gasPrice"`
+
+        // Fee-market pricing (see fees.go); nil for legacy GasPrice txs.
+        MaxFeePerGas         *big.Int `json:"maxFeePerGas,omitempty"`
+        MaxPriorityFeePerGas *big.Int `json:"maxPriorityFeePerGas,omitempty"`
+
         GasLimit  uint64    `json:"gasLimit"`
         Data      []byte    `json:"data"`
         Signature Signature `json:"sig"`
 
+        // Multisig spending (see multisig.go): the sending multisig account
+        // and its signers' signatures, used instead of Signature.
+        Multisig     *Address `json:"multisig,omitempty"`
+        Cosignatures [][]byte `json:"cosignatures,omitempty"`
+
         from *Address `json:"-"`
         hash Hash     `json:"-"`
 }
 
+// NewStakingTx builds an unsigned Stake, Unstake, Delegate or Undelegate
+// transaction. validator is ignored for Stake and Unstake.
+func NewStakingTx(
+        chainId uint64,
+        txType TxType,
+        nonce uint64,
+        validator Address,
+        amount, gasPrice *big.Int,
+        gasLimit uint64,
+) *Transaction {
+        tx := NewTransferTx(chainId, nonce, validator, amount, gasPrice, gasLimit, nil)
+        tx.Type = txType
+        if txType == TxTypeStake || txType == TxTypeUnstake {
+                tx.To = Address{}
+        }
+        return tx
+}
+
+// NewEvidenceTx builds an unsigned transaction submitting ev.
+func NewEvidenceTx(chainId uint64, nonce uint64, ev *Evidence, gasPrice *big.Int, gasLimit uint64) (*Transaction, error) {
+        data, err := EncodeEvidence(ev)
+        if err != nil {
+                return nil, err
+        }
+        tx := NewTransferTx(chainId, nonce, Address{}, nil, gasPrice, gasLimit, data)
+        tx.Type = TxTypeEvidence
+        return tx, nil
+}
+
 func NewTransferTx(
         chainId uint64,
         nonce uint64,
@@ -97,6 +152,16 @@ func (tx *Transaction) HashForSign() Hash {
                 h.Write(tx.Data)
         }
 
+        // Legacy txs keep their original signing hash.
+        if tx.IsDynamicFee() {
+                writeBig(h, tx.MaxFeePerGas)
+                writeBig(h, tx.MaxPriorityFeePerGas)
+        }
+        if tx.IsMultisig() {
+                h.Write([]byte("multisig"))
+                h.Write(tx.Multisig[:])
+        }
+
         var out Hash
         copy(out[:], h.Sum(nil))
         return out
@@ -115,6 +180,9 @@ func (tx *Transaction) Hash() Hash {
         writeBig(h, tx.Signature.R)
         writeBig(h, tx.Signature.S)
         h.Write([]byte{tx.Signature.V})
+        for _, sig := range tx.Cosignatures {
+                h.Write(sig)
+        }
 
         copy(tx.hash[:], h.Sum(nil))
         return tx.hash
@@ -137,21 +205,120 @@ func (tx *Transaction) ValidateBasic() error {
         if tx.ChainId == nil || tx.ChainId.Sign() <= 0 {
                 return errors.New("invalid chainId")
         }
-        if tx.Type != TxTypeTransfer {
-                return errors.New("unsupported tx type")
-        }
         if tx.Value == nil || tx.Value.Sign() < 0 {
                 return errors.New("invalid value")
         }
+        switch tx.Type {
+        case TxTypeTransfer:
+        case TxTypeStake, TxTypeUnstake:
+                if tx.Value.Sign() == 0 {
+                        return errors.New("stake amount must be > 0")
+                }
+                if !tx.To.IsZero() {
+                        return errors.New("stake tx must not set a recipient")
+                }
+        case TxTypeDelegate, TxTypeUndelegate:
+                if tx.Value.Sign() == 0 {
+                        return errors.New("delegation amount must be > 0")
+                }
+                if tx.To.IsZero() {
+                        return errors.New("delegation tx requires a validator")
+                }
+        case TxTypeEvidence:
+                if tx.Value.Sign() != 0 {
+                        return errors.New("evidence tx must not carry value")
+                }
+                if len(tx.Data) == 0 {
+                        return errors.New("evidence tx requires evidence data")
+                }
+        case TxTypeFreeze:
+                if tx.Value.Sign() != 0 {
+                        return errors.New("freeze tx must not carry value")
+                }
+                if len(tx.Data) == 0 {
+                        return errors.New("freeze tx requires an action")
+                }
+        case TxTypeBatch:
+                if !tx.To.IsZero() {
+                        return errors.New("batch tx must not set a recipient")
+                }
+                if err := validateBatch(tx); err != nil {
+                        return err
+                }
+        case TxTypeMultisigCreate:
+                if !tx.To.IsZero() {
+                        return errors.New("multisig create tx must not set a recipient")
+                }
+                if _, err := DecodeMultisigPolicy(tx.Data); err != nil {
+                        return err
+                }
+        case TxTypeMultisigUpdate:
+                if !tx.IsMultisig() {
+                        return ErrNotMultisig
+                }
+                if tx.Value.Sign() != 0 || !tx.To.IsZero() {
+                        return errors.New("multisig update tx must not carry value or a recipient")
+                }
+                if _, err := DecodeMultisigPolicy(tx.Data); err != nil {
+                        return err
+                }
+        default:
+                return errors.New("unsupported tx type")
+        }
+        if tx.IsMultisig() && tx.Multisig.IsZero() {
+                return ErrNotMultisig
+        }
         if tx.GasLimit == 0 {
                 return errors.New("gasLimit must > 0")
         }
+        if tx.GasLimit < IntrinsicGas(tx) {
+                return ErrIntrinsicGas
+        }
         if tx.GasPrice == nil || tx.GasPrice.Sign() < 0 {
                 return errors.New("invalid gas price")
         }
+        if tx.IsDynamicFee() {
+                if tx.MaxPriorityFeePerGas == nil || tx.MaxPriorityFeePerGas.Sign() < 0 || tx.MaxFeePerGas.Sign() < 0 {
+                        return errors.New("invalid fee caps")
+                }
+                if tx.MaxPriorityFeePerGas.Cmp(tx.MaxFeePerGas) > 0 {
+                        return errors.New("max priority fee exceeds max fee")
+                }
+        }
+        return nil
+}
+
+// CheckChainID returns ErrWrongChain unless tx is signed for chainID.
+func (tx *Transaction) CheckChainID(chainID uint64) error {
+        if tx.ChainId == nil || !tx.ChainId.IsUint64() || tx.ChainId.Uint64() != chainID {
+                return ErrWrongChain
+        }
         return nil
 }
 
+// CheckNonce returns ErrNonceTooLow or ErrNonceTooHigh unless tx's nonce
+// is the sender's account nonce.
+func (tx *Transaction) CheckNonce(accountNonce uint64) error {
+        switch {
+        case tx.Nonce < accountNonce:
+                return ErrNonceTooLow
+        case tx.Nonce > accountNonce:
+                return ErrNonceTooHigh
+        }
+        return nil
+}
+
+// Cost returns the maximum balance the transaction can spend: the gas fee
+// plus the value for types that move value out of the balance.
+func (tx *Transaction) Cost() *big.Int {
+        cost := new(big.Int).Mul(new(big.Int).SetUint64(tx.GasLimit), tx.FeeCap())
+        switch tx.Type {
+        case TxTypeUnstake, TxTypeUndelegate:
+                return cost
+        }
+        return cost.Add(cost, tx.Value)
+}
+
 func (tx *Transaction) SetFrom(a Address) {
         tx.from = &a
 }
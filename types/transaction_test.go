@@ -0,0 +1,61 @@
+// SPDX-License-Identifier: MIT
+// Dev: KryperAI
+
+package types
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestReplayRejected(t *testing.T) {
+	key, rich := newTestKey(t)
+	c := newTestChain(t, rich)
+	tx := signedTransfer(t, key, 0, Address{1}, 1, 1)
+	c.mine(t, []*Transaction{tx}, Address{}, 1)
+
+	h, _ := c.nextHeader(Address{}, 2)
+	c.exec.SetCurrentHeader(h)
+	if _, err := c.exec.ExecuteTx(tx); err != ErrNonceTooLow {
+		t.Fatalf("executor replay: %v", err)
+	}
+	if err := c.pool.AddTx(tx); err != ErrNonceTooLow {
+		t.Fatalf("mempool replay: %v", err)
+	}
+}
+
+func TestNonceTooHigh(t *testing.T) {
+	key, rich := newTestKey(t)
+	c := newTestChain(t, rich)
+	h, _ := c.nextHeader(Address{}, 1)
+	c.exec.SetCurrentHeader(h)
+	if _, err := c.exec.ExecuteTx(signedTransfer(t, key, 5, Address{1}, 1, 1)); err != ErrNonceTooHigh {
+		t.Fatalf("got %v", err)
+	}
+}
+
+func TestWrongChainRejected(t *testing.T) {
+	key, rich := newTestKey(t)
+	c := newTestChain(t, rich)
+	tx := NewTransferTx(2, 0, Address{1}, big.NewInt(1), big.NewInt(1), TxGas, nil)
+	if err := SignTransaction(tx, key); err != nil {
+		t.Fatal(err)
+	}
+	h, _ := c.nextHeader(Address{}, 1)
+	c.exec.SetCurrentHeader(h)
+	if _, err := c.exec.ExecuteTx(tx); err != ErrWrongChain {
+		t.Fatalf("executor: %v", err)
+	}
+	if err := c.pool.AddTx(tx); err != ErrWrongChain {
+		t.Fatalf("mempool: %v", err)
+	}
+}
+
+func TestSignatureCoversChainID(t *testing.T) {
+	key, addr := newTestKey(t)
+	tx := signedTransfer(t, key, 0, Address{1}, 1, 1)
+	tx.ChainId = big.NewInt(2)
+	if from, err := RecoverTxSender(tx); err == nil && from == addr {
+		t.Fatal("signature still valid after changing the chain id")
+	}
+}
@@ -0,0 +1,438 @@
+// SPDX-License-Identifier: MIT
+// Dev: KryperAI
+
+package types
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+
+	"krypper-chain/storage"
+)
+
+// Trie is a compact sparse Merkle tree over 256-bit keys.
+//
+// Keys are walked bit by bit (MSB first). A subtree holding a single entry
+// is stored as a leaf at the shallowest depth where it is unique, so the
+// tree depth grows with log2(entries) instead of 256. Hashing:
+//
+//	empty  = 0x00..00
+//	leaf   = sha256(0x00 || key || sha256(value))
+//	branch = sha256(0x01 || left || right)
+//
+// Nodes are immutable once built: updates copy the path from the root, so
+// holding an old root keeps that version readable (used by snapshots), and
+// committed nodes are content-addressed in the database so any historical
+// root can be reopened.
+//
+// Commit only stages nodes into a writer. They count as stored once the
+// caller reports the write succeeded with MarkStored; until then later
+// commits skip them, and DiscardPending makes them be written again.
+type Trie struct {
+	db      storage.Database
+	root    trieNode
+	pending map[trieNode]struct{}
+}
+
+var triePrefix = []byte("t")
+
+const (
+	trieLeafTag   byte = 0x00
+	trieBranchTag byte = 0x01
+)
+
+type trieNode interface {
+	hash() Hash
+}
+
+type trieLeaf struct {
+	key    Hash
+	value  []byte
+	cached Hash
+	stored bool
+}
+
+type trieBranch struct {
+	left, right trieNode
+	cached      Hash
+	stored      bool
+}
+
+// trieRef is an unresolved node known only by hash; it is loaded on demand.
+type trieRef Hash
+
+func (l *trieLeaf) hash() Hash {
+	if l.cached.IsZero() {
+		vh := sha256.Sum256(l.value)
+		h := sha256.New()
+		h.Write([]byte{trieLeafTag})
+		h.Write(l.key[:])
+		h.Write(vh[:])
+		copy(l.cached[:], h.Sum(nil))
+	}
+	return l.cached
+}
+
+func (b *trieBranch) hash() Hash {
+	if b.cached.IsZero() {
+		lh, rh := nodeHash(b.left), nodeHash(b.right)
+		h := sha256.New()
+		h.Write([]byte{trieBranchTag})
+		h.Write(lh[:])
+		h.Write(rh[:])
+		copy(b.cached[:], h.Sum(nil))
+	}
+	return b.cached
+}
+
+func (r trieRef) hash() Hash { return Hash(r) }
+
+func nodeHash(n trieNode) Hash {
+	if n == nil {
+		return ZeroHash()
+	}
+	return n.hash()
+}
+
+// NewTrie opens the trie with the given root. A zero root is the empty trie.
+func NewTrie(db storage.Database, root Hash) (*Trie, error) {
+	t := &Trie{db: db}
+	if root.IsZero() {
+		return t, nil
+	}
+	n, err := t.resolve(trieRef(root))
+	if err != nil {
+		return nil, err
+	}
+	t.root = n
+	return t, nil
+}
+
+// Root returns the current root hash.
+func (t *Trie) Root() Hash {
+	return nodeHash(t.root)
+}
+
+// Copy returns an independent handle sharing the immutable nodes.
+func (t *Trie) Copy() *Trie {
+	return &Trie{db: t.db, root: t.root}
+}
+
+// Get returns the value stored under key, or nil if absent.
+func (t *Trie) Get(key Hash) ([]byte, error) {
+	n := t.root
+	for depth := 0; ; depth++ {
+		var err error
+		if n, err = t.resolve(n); err != nil {
+			return nil, err
+		}
+		switch node := n.(type) {
+		case nil:
+			return nil, nil
+		case *trieLeaf:
+			if node.key != key {
+				return nil, nil
+			}
+			return node.value, nil
+		case *trieBranch:
+			if keyBit(key, depth) == 0 {
+				n = node.left
+			} else {
+				n = node.right
+			}
+		}
+	}
+}
+
+// Update sets key to value. An empty value deletes the key.
+func (t *Trie) Update(key Hash, value []byte) error {
+	if len(value) == 0 {
+		return t.Delete(key)
+	}
+	leaf := &trieLeaf{key: key, value: bytes.Clone(value)}
+	n, err := t.insert(t.root, leaf, 0)
+	if err != nil {
+		return err
+	}
+	t.root = n
+	return nil
+}
+
+// Delete removes key from the trie. Deleting a missing key is a no-op.
+func (t *Trie) Delete(key Hash) error {
+	n, err := t.remove(t.root, key, 0)
+	if err != nil {
+		return err
+	}
+	t.root = n
+	return nil
+}
+
+func (t *Trie) insert(n trieNode, leaf *trieLeaf, depth int) (trieNode, error) {
+	n, err := t.resolve(n)
+	if err != nil {
+		return nil, err
+	}
+	switch node := n.(type) {
+	case nil:
+		return leaf, nil
+	case *trieLeaf:
+		if node.key == leaf.key {
+			return leaf, nil
+		}
+		return splitLeaves(node, leaf, depth), nil
+	case *trieBranch:
+		out := &trieBranch{left: node.left, right: node.right}
+		if keyBit(leaf.key, depth) == 0 {
+			out.left, err = t.insert(node.left, leaf, depth+1)
+		} else {
+			out.right, err = t.insert(node.right, leaf, depth+1)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return out, nil
+	}
+	return nil, errors.New("trie: unknown node")
+}
+
+// splitLeaves builds the branch chain separating two leaves that share
+// the key prefix up to depth.
+func splitLeaves(a, b *trieLeaf, depth int) trieNode {
+	ba, bb := keyBit(a.key, depth), keyBit(b.key, depth)
+	if ba == bb {
+		child := splitLeaves(a, b, depth+1)
+		if ba == 0 {
+			return &trieBranch{left: child}
+		}
+		return &trieBranch{right: child}
+	}
+	if ba == 0 {
+		return &trieBranch{left: a, right: b}
+	}
+	return &trieBranch{left: b, right: a}
+}
+
+func (t *Trie) remove(n trieNode, key Hash, depth int) (trieNode, error) {
+	n, err := t.resolve(n)
+	if err != nil {
+		return nil, err
+	}
+	switch node := n.(type) {
+	case nil:
+		return nil, nil
+	case *trieLeaf:
+		if node.key == key {
+			return nil, nil
+		}
+		return node, nil
+	case *trieBranch:
+		left, right := node.left, node.right
+		if keyBit(key, depth) == 0 {
+			left, err = t.remove(node.left, key, depth+1)
+		} else {
+			right, err = t.remove(node.right, key, depth+1)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if left == node.left && right == node.right {
+			return node, nil
+		}
+		return t.collapse(left, right)
+	}
+	return nil, errors.New("trie: unknown node")
+}
+
+// collapse keeps the compact invariant: a subtree with a single leaf is
+// represented by that leaf.
+func (t *Trie) collapse(left, right trieNode) (trieNode, error) {
+	if left == nil && right == nil {
+		return nil, nil
+	}
+	if left == nil || right == nil {
+		only := left
+		if only == nil {
+			only = right
+		}
+		only, err := t.resolve(only)
+		if err != nil {
+			return nil, err
+		}
+		if leaf, ok := only.(*trieLeaf); ok {
+			return leaf, nil
+		}
+	}
+	return &trieBranch{left: left, right: right}, nil
+}
+
+// Iterate calls fn for every entry in key order until fn returns false.
+func (t *Trie) Iterate(fn func(key Hash, value []byte) bool) error {
+	_, err := t.iterate(t.root, fn)
+	return err
+}
+
+func (t *Trie) iterate(n trieNode, fn func(Hash, []byte) bool) (bool, error) {
+	n, err := t.resolve(n)
+	if err != nil {
+		return false, err
+	}
+	switch node := n.(type) {
+	case nil:
+		return true, nil
+	case *trieLeaf:
+		return fn(node.key, node.value), nil
+	case *trieBranch:
+		cont, err := t.iterate(node.left, fn)
+		if err != nil || !cont {
+			return cont, err
+		}
+		return t.iterate(node.right, fn)
+	}
+	return false, errors.New("trie: unknown node")
+}
+
+// Commit writes all nodes neither stored nor pending into w and returns
+// the root. The written nodes stay pending until MarkStored.
+func (t *Trie) Commit(w storage.Writer) (Hash, error) {
+	if t.pending == nil {
+		t.pending = make(map[trieNode]struct{})
+	}
+	if err := t.commitNode(w, t.root); err != nil {
+		return Hash{}, err
+	}
+	return t.Root(), nil
+}
+
+// MarkStored records the pending nodes as persisted. Call it only after
+// the writers passed to Commit have been written.
+func (t *Trie) MarkStored() {
+	for n := range t.pending {
+		switch node := n.(type) {
+		case *trieLeaf:
+			node.stored = true
+		case *trieBranch:
+			node.stored = true
+		}
+	}
+	t.pending = nil
+}
+
+// DiscardPending forgets the pending nodes after a failed write, so the
+// next Commit writes them again.
+func (t *Trie) DiscardPending() {
+	t.pending = nil
+}
+
+func (t *Trie) commitNode(w storage.Writer, n trieNode) error {
+	if _, ok := t.pending[n]; ok {
+		return nil
+	}
+	switch node := n.(type) {
+	case *trieLeaf:
+		if node.stored {
+			return nil
+		}
+		if err := w.Put(trieKey(node.hash()), encodeTrieNode(node)); err != nil {
+			return err
+		}
+	case *trieBranch:
+		if node.stored {
+			return nil
+		}
+		if err := t.commitNode(w, node.left); err != nil {
+			return err
+		}
+		if err := t.commitNode(w, node.right); err != nil {
+			return err
+		}
+		if err := w.Put(trieKey(node.hash()), encodeTrieNode(node)); err != nil {
+			return err
+		}
+	default:
+		return nil
+	}
+	t.pending[n] = struct{}{}
+	return nil
+}
+
+// resolve loads a trieRef from the database; other nodes pass through.
+func (t *Trie) resolve(n trieNode) (trieNode, error) {
+	ref, ok := n.(trieRef)
+	if !ok {
+		return n, nil
+	}
+	if t.db == nil {
+		return nil, errors.New("trie: missing database")
+	}
+	data, err := t.db.Get(trieKey(Hash(ref)))
+	if err != nil {
+		return nil, err
+	}
+	node, err := decodeTrieNode(data)
+	if err != nil {
+		return nil, err
+	}
+	if node.hash() != Hash(ref) {
+		return nil, errors.New("trie: node hash mismatch")
+	}
+	return node, nil
+}
+
+func trieKey(h Hash) []byte {
+	return append(append([]byte{}, triePrefix...), h[:]...)
+}
+
+func encodeTrieNode(n trieNode) []byte {
+	switch node := n.(type) {
+	case *trieLeaf:
+		out := make([]byte, 0, 1+32+len(node.value))
+		out = append(out, trieLeafTag)
+		out = append(out, node.key[:]...)
+		return append(out, node.value...)
+	case *trieBranch:
+		lh, rh := nodeHash(node.left), nodeHash(node.right)
+		out := make([]byte, 0, 1+64)
+		out = append(out, trieBranchTag)
+		out = append(out, lh[:]...)
+		return append(out, rh[:]...)
+	}
+	return nil
+}
+
+func decodeTrieNode(data []byte) (trieNode, error) {
+	if len(data) == 0 {
+		return nil, errors.New("trie: empty node")
+	}
+	switch data[0] {
+	case trieLeafTag:
+		if len(data) < 1+32 {
+			return nil, errors.New("trie: short leaf")
+		}
+		leaf := &trieLeaf{value: bytes.Clone(data[33:]), stored: true}
+		copy(leaf.key[:], data[1:33])
+		return leaf, nil
+	case trieBranchTag:
+		if len(data) != 1+64 {
+			return nil, errors.New("trie: bad branch length")
+		}
+		b := &trieBranch{stored: true}
+		var lh, rh Hash
+		copy(lh[:], data[1:33])
+		copy(rh[:], data[33:65])
+		if !lh.IsZero() {
+			b.left = trieRef(lh)
+		}
+		if !rh.IsZero() {
+			b.right = trieRef(rh)
+		}
+		return b, nil
+	}
+	return nil, errors.New("trie: unknown node tag")
+}
+
+// keyBit returns bit i of key, counting from the most significant bit.
+func keyBit(key Hash, i int) byte {
+	return (key[i/8] >> (7 - uint(i%8))) & 1
+}
@@ -0,0 +1,136 @@
+// SPDX-License-Identifier: MIT
+// Dev: KryperAI
+
+package types
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"krypper-chain/storage"
+)
+
+func testTrieKeys(n int, prefix string) []Hash {
+	keys := make([]Hash, n)
+	for i := range keys {
+		keys[i] = sha256.Sum256([]byte(fmt.Sprint(prefix, i)))
+	}
+	return keys
+}
+
+func TestTrieRootIsOrderIndependent(t *testing.T) {
+	db := storage.NewMemoryDB()
+	keys := testTrieKeys(300, "")
+
+	a, _ := NewTrie(db, Hash{})
+	for i, k := range keys {
+		a.Update(k, []byte(fmt.Sprint("v", i)))
+	}
+
+	b, _ := NewTrie(db, Hash{})
+	for _, i := range rand.New(rand.NewSource(1)).Perm(len(keys)) {
+		b.Update(keys[i], []byte(fmt.Sprint("v", i)))
+	}
+	// Keys inserted and deleted again must leave no trace in the root.
+	extra := testTrieKeys(50, "x")
+	for _, k := range extra {
+		b.Update(k, []byte("junk"))
+	}
+	for _, k := range extra {
+		b.Delete(k)
+	}
+
+	if a.Root() != b.Root() {
+		t.Fatalf("root mismatch: %s != %s", a.Root(), b.Root())
+	}
+}
+
+func TestTrieCommitAndReload(t *testing.T) {
+	db := storage.NewMemoryDB()
+	keys := testTrieKeys(300, "")
+
+	tr, _ := NewTrie(db, Hash{})
+	for i, k := range keys {
+		tr.Update(k, []byte(fmt.Sprint("v", i)))
+	}
+	root, err := tr.Commit(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := NewTrie(db, root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, k := range keys {
+		v, err := loaded.Get(k)
+		if err != nil || string(v) != fmt.Sprint("v", i) {
+			t.Fatalf("get %d: %q, %v", i, v, err)
+		}
+	}
+
+	// Deleting half the keys gives the root of a trie built from the rest.
+	for _, k := range keys[:150] {
+		loaded.Delete(k)
+	}
+	want, _ := NewTrie(db, Hash{})
+	for i, k := range keys[150:] {
+		want.Update(k, []byte(fmt.Sprint("v", i+150)))
+	}
+	if loaded.Root() != want.Root() {
+		t.Fatal("root after delete does not match rebuilt trie")
+	}
+}
+
+func TestTrieEmptyRoot(t *testing.T) {
+	tr, _ := NewTrie(storage.NewMemoryDB(), Hash{})
+	if !tr.Root().IsZero() {
+		t.Fatal("empty trie root is not zero")
+	}
+	k := testTrieKeys(1, "")[0]
+	tr.Update(k, []byte("v"))
+	tr.Delete(k)
+	if !tr.Root().IsZero() {
+		t.Fatal("root not zero after deleting the only key")
+	}
+	if v, err := tr.Get(k); err != nil || v != nil {
+		t.Fatalf("deleted key still present: %q, %v", v, err)
+	}
+}
+
+func TestTrieRecommitAfterFailedWrite(t *testing.T) {
+	db := storage.NewMemoryDB()
+	keys := testTrieKeys(50, "")
+	tr, _ := NewTrie(db, Hash{})
+	for i, k := range keys {
+		tr.Update(k, []byte(fmt.Sprint("v", i)))
+	}
+
+	// The batch is dropped as if its write failed.
+	if _, err := tr.Commit(db.NewBatch()); err != nil {
+		t.Fatal(err)
+	}
+	tr.DiscardPending()
+
+	batch := db.NewBatch()
+	root, err := tr.Commit(batch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := batch.Write(); err != nil {
+		t.Fatal(err)
+	}
+	tr.MarkStored()
+
+	loaded, err := NewTrie(db, root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, k := range keys {
+		if v, err := loaded.Get(k); err != nil || string(v) != fmt.Sprint("v", i) {
+			t.Fatalf("get %d: %q, %v", i, v, err)
+		}
+	}
+}
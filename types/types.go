@@ -4,7 +4,11 @@
 package types
 
 import (
+        "bytes"
         "encoding/hex"
+        "encoding/json"
+        "errors"
+        "strings"
 )
 
 // =========================
@@ -25,6 +29,65 @@ func ZeroHash() Hash {
         return Hash{}
 }
 
+// ParseHash converts a 0x prefixed hex string -> Hash.
+func ParseHash(s string) (Hash, error) {
+        s = strings.TrimPrefix(strings.TrimSpace(s), "0x")
+        if len(s) != 64 {
+                return Hash{}, errors.New("invalid hash length")
+        }
+        data, err := hex.DecodeString(s)
+        if err != nil {
+                return Hash{}, err
+        }
+        var h Hash
+        copy(h[:], data)
+        return h, nil
+}
+
+// MarshalText encodes the hash as 0x prefixed hex (used by JSON).
+func (h Hash) MarshalText() ([]byte, error) {
+        return []byte(h.String()), nil
+}
+
+// UnmarshalText decodes a 0x prefixed hex hash.
+func (h *Hash) UnmarshalText(text []byte) error {
+        parsed, err := ParseHash(string(text))
+        if err != nil {
+                return err
+        }
+        *h = parsed
+        return nil
+}
+
+// UnmarshalJSON decodes a hex string, or the array of byte values hashes
+// were encoded as before they implemented MarshalText, so blocks and
+// accounts written by older nodes still load.
+func (h *Hash) UnmarshalJSON(input []byte) error {
+        if isJSONArray(input) {
+                return json.Unmarshal(input, (*[32]byte)(h))
+        }
+        return unmarshalJSONText(input, h.UnmarshalText)
+}
+
+// isJSONArray reports whether input is a JSON array.
+func isJSONArray(input []byte) bool {
+        input = bytes.TrimSpace(input)
+        return len(input) > 0 && input[0] == '['
+}
+
+// unmarshalJSONText decodes a JSON string and passes it to unmarshal.
+// A JSON null leaves the value untouched.
+func unmarshalJSONText(input []byte, unmarshal func([]byte) error) error {
+        if string(bytes.TrimSpace(input)) == "null" {
+                return nil
+        }
+        var s string
+        if err := json.Unmarshal(input, &s); err != nil {
+                return err
+        }
+        return unmarshal([]byte(s))
+}
+
 // Address.IsZero checks if address is zero address
 func (a Address) IsZero() bool {
         return a == Address{}
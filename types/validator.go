@@ -119,4 +119,49 @@ func VerifyValidatorVote(v *ValidatorVote) (Address, error) {
 	}
 
 	return recovered, nil
-}
\ No newline at end of file
+}
+// ErrValidatorSignature is returned for a header whose Tier-2 validator
+// did not vote for its parent.
+var ErrValidatorSignature = errors.New("validator signature does not match address")
+
+// SigBytes returns the vote signature as 65 bytes [R || S || V], the form
+// carried in BlockHeader.ValidatorSig.
+func (v *ValidatorVote) SigBytes() []byte {
+	sig := make([]byte, 65)
+	if v.R != nil {
+		copy(sig[0:32], padTo32(v.R.Bytes()))
+	}
+	if v.S != nil {
+		copy(sig[32:64], padTo32(v.S.Bytes()))
+	}
+	sig[64] = v.V
+	return sig
+}
+
+// verifyHeaderValidator checks that the Tier-2 validator of h voted for
+// its parent, so a proposer cannot credit a validator that never attested
+// its branch.
+func verifyHeaderValidator(h *BlockHeader) error {
+	if h.Validator.IsZero() {
+		if len(h.ValidatorSig) != 0 {
+			return ErrValidatorSignature
+		}
+		return nil
+	}
+	if len(h.ValidatorSig) != 65 || h.Height == 0 {
+		return ErrValidatorSignature
+	}
+	v := &ValidatorVote{
+		ChainID: h.ChainID,
+		Height:  h.Height - 1,
+		Block:   h.ParentHash,
+		Voter:   h.Validator,
+		R:       new(big.Int).SetBytes(h.ValidatorSig[0:32]),
+		S:       new(big.Int).SetBytes(h.ValidatorSig[32:64]),
+		V:       h.ValidatorSig[64],
+	}
+	if _, err := VerifyValidatorVote(v); err != nil {
+		return ErrValidatorSignature
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+// SPDX-License-Identifier: MIT
+// Dev: KryperAI
+
+package types
+
+import (
+	"bytes"
+	"math/big"
+	"sort"
+)
+
+// ValidatorStake is one member of a validator set with its voting weight
+// (self stake plus delegations). SelfStake is only set for selection
+// candidates and is not kept in the set.
+type ValidatorStake struct {
+	Address   Address  `json:"address"`
+	Stake     *big.Int `json:"stake"`
+	SelfStake *big.Int `json:"selfStake,omitempty"`
+}
+
+// ValidatorSet is an immutable, address-ordered set of Tier-2 validators.
+type ValidatorSet struct {
+	validators []ValidatorStake
+	index      map[Address]int
+	total      *big.Int
+}
+
+// NewValidatorSet builds a set, merging duplicate addresses and dropping
+// members without positive stake.
+func NewValidatorSet(vals []ValidatorStake) *ValidatorSet {
+	merged := make(map[Address]*big.Int)
+	for _, v := range vals {
+		if v.Stake == nil || v.Stake.Sign() <= 0 {
+			continue
+		}
+		if cur, ok := merged[v.Address]; ok {
+			cur.Add(cur, v.Stake)
+			continue
+		}
+		merged[v.Address] = new(big.Int).Set(v.Stake)
+	}
+
+	vs := &ValidatorSet{
+		validators: make([]ValidatorStake, 0, len(merged)),
+		index:      make(map[Address]int, len(merged)),
+		total:      big.NewInt(0),
+	}
+	for addr, stake := range merged {
+		vs.validators = append(vs.validators, ValidatorStake{Address: addr, Stake: stake})
+		vs.total.Add(vs.total, stake)
+	}
+	sort.Slice(vs.validators, func(i, j int) bool {
+		return bytes.Compare(vs.validators[i].Address[:], vs.validators[j].Address[:]) < 0
+	})
+	for i, v := range vs.validators {
+		vs.index[v.Address] = i
+	}
+	return vs
+}
+
+// Len returns the number of validators.
+func (vs *ValidatorSet) Len() int {
+	if vs == nil {
+		return 0
+	}
+	return len(vs.validators)
+}
+
+// Contains reports whether addr is a member.
+func (vs *ValidatorSet) Contains(addr Address) bool {
+	if vs == nil {
+		return false
+	}
+	_, ok := vs.index[addr]
+	return ok
+}
+
+// StakeOf returns the voting weight of addr (zero for non-members).
+func (vs *ValidatorSet) StakeOf(addr Address) *big.Int {
+	if vs == nil {
+		return big.NewInt(0)
+	}
+	i, ok := vs.index[addr]
+	if !ok {
+		return big.NewInt(0)
+	}
+	return new(big.Int).Set(vs.validators[i].Stake)
+}
+
+// TotalStake returns the sum of all members' stake.
+func (vs *ValidatorSet) TotalStake() *big.Int {
+	if vs == nil {
+		return big.NewInt(0)
+	}
+	return new(big.Int).Set(vs.total)
+}
+
+// Validators returns a copy of the members in address order.
+func (vs *ValidatorSet) Validators() []ValidatorStake {
+	if vs == nil {
+		return nil
+	}
+	out := make([]ValidatorStake, len(vs.validators))
+	for i, v := range vs.validators {
+		out[i] = ValidatorStake{Address: v.Address, Stake: new(big.Int).Set(v.Stake)}
+	}
+	return out
+}
+
+// HasQuorum reports whether stake is strictly more than 2/3 of the total.
+func (vs *ValidatorSet) HasQuorum(stake *big.Int) bool {
+	if vs == nil || vs.total.Sign() == 0 || stake == nil {
+		return false
+	}
+	lhs := new(big.Int).Mul(stake, big.NewInt(3))
+	rhs := new(big.Int).Mul(vs.total, big.NewInt(2))
+	return lhs.Cmp(rhs) > 0
+}
+
+// SelectValidatorSet picks the top count stakers by voting weight among
+// those whose self stake is at least minStake. Ties in weight are broken by
+// lower address so every node selects the same set. A count <= 0 means no
+// limit.
+func SelectValidatorSet(stakers []ValidatorStake, count int, minStake *big.Int) *ValidatorSet {
+	eligible := make([]ValidatorStake, 0, len(stakers))
+	for _, v := range stakers {
+		if v.Stake == nil || v.Stake.Sign() <= 0 {
+			continue
+		}
+		self := v.SelfStake
+		if self == nil {
+			self = v.Stake
+		}
+		if minStake != nil && self.Cmp(minStake) < 0 {
+			continue
+		}
+		eligible = append(eligible, v)
+	}
+	sort.Slice(eligible, func(i, j int) bool {
+		if c := eligible[i].Stake.Cmp(eligible[j].Stake); c != 0 {
+			return c > 0
+		}
+		return bytes.Compare(eligible[i].Address[:], eligible[j].Address[:]) < 0
+	})
+	if count > 0 && len(eligible) > count {
+		eligible = eligible[:count]
+	}
+	return NewValidatorSet(eligible)
+}
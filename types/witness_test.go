@@ -0,0 +1,49 @@
+// SPDX-License-Identifier: MIT
+// Dev: KryperAI
+
+package types
+
+import "testing"
+
+func TestVerifyWitness(t *testing.T) {
+	_, rich := newTestKey(t)
+	c := newTestChain(t, rich)
+	for i := int64(1); i <= 5; i++ {
+		c.mine(t, nil, Address{}, i)
+	}
+	wk, _, _ := GenerateKey()
+	b4 := c.chain.GetBlockByHeight(4)
+
+	w, _ := SignWitness(wk, 4, b4.Hash())
+	if err := VerifyWitness(w, c.chain); err != nil {
+		t.Fatal(err)
+	}
+
+	moved := *w
+	moved.BlockHeight = 3 // signature covers the height
+	if err := VerifyWitness(&moved, c.chain); err != ErrWitnessSignature {
+		t.Fatalf("moved height: %v", err)
+	}
+
+	other, _, _ := GenerateKey()
+	forged := *w
+	forged.Address = PrivateKeyToAddress(other)
+	if err := VerifyWitness(&forged, c.chain); err != ErrWitnessSignature {
+		t.Fatalf("forged address: %v", err)
+	}
+
+	unknown, _ := SignWitness(wk, 4, Hash{9})
+	if err := VerifyWitness(unknown, c.chain); err != ErrWitnessUnknownBlock {
+		t.Fatalf("unknown block: %v", err)
+	}
+
+	future, _ := SignWitness(wk, 6, Hash{9})
+	if err := VerifyWitness(future, c.chain); err != ErrWitnessUnknownBlock {
+		t.Fatalf("future block: %v", err)
+	}
+
+	stale, _ := SignWitness(wk, 1, c.chain.GetBlockByHeight(1).Hash())
+	if err := VerifyWitness(stale, c.chain); err != ErrWitnessStale {
+		t.Fatalf("stale block: %v", err)
+	}
+}
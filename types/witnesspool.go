@@ -0,0 +1,252 @@
+// SPDX-License-Identifier: MIT
+// Dev: KryperAI
+
+package types
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"sort"
+	"sync"
+)
+
+// DefaultWitnessPoolSize bounds the pending witnesses of a pool. An
+// address holds at most one witness per height.
+const DefaultWitnessPoolSize = 10000
+
+// MaxWitnessCandidates bounds the witnesses of the parent a header commits
+// (see BlockHeader.WitnessCandidates).
+const MaxWitnessCandidates = 32
+
+var ErrWitnessPoolFull = errors.New("witness pool is full")
+
+// WitnessPool holds verified Tier-3 witnesses by witnessed height until
+// they expire, so the block producer can commit the witnesses of its
+// parent and include the selected one's signature.
+type WitnessPool struct {
+	mu        sync.Mutex
+	byHeight  map[uint64]map[Address]Witness
+	size      int
+	maxSize   int
+	minHeight uint64
+}
+
+func NewWitnessPool() *WitnessPool {
+	return &WitnessPool{
+		byHeight: make(map[uint64]map[Address]Witness),
+		maxSize:  DefaultWitnessPoolSize,
+	}
+}
+
+// Add stores an already verified witness. Witnesses that have expired
+// relative to head are dropped first, so the pool only fills with heights
+// a witness can still be submitted for, even on nodes that do not produce
+// blocks.
+func (p *WitnessPool) Add(w Witness, head uint64) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.prune(head)
+	if w.BlockHeight < p.minHeight {
+		return ErrWitnessStale
+	}
+	set := p.byHeight[w.BlockHeight]
+	if _, ok := set[w.Address]; ok {
+		return ErrDuplicateWitness
+	}
+	if p.size >= p.maxSize {
+		return ErrWitnessPoolFull
+	}
+
+	if set == nil {
+		set = make(map[Address]Witness)
+		p.byHeight[w.BlockHeight] = set
+	}
+	set[w.Address] = w
+	p.size++
+	return nil
+}
+
+// Prune drops witnesses more than WitnessMaxAge blocks behind head.
+func (p *WitnessPool) Prune(head uint64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.prune(head)
+}
+
+// prune is Prune without locking. Caller must hold p.mu.
+func (p *WitnessPool) prune(head uint64) {
+	if head < WitnessMaxAge {
+		return
+	}
+	p.minHeight = head - WitnessMaxAge
+	for height, set := range p.byHeight {
+		if height >= p.minHeight {
+			continue
+		}
+		p.size -= len(set)
+		delete(p.byHeight, height)
+	}
+}
+
+// Candidates returns the witnesses of the block with the given height and
+// hash, ordered by address.
+func (p *WitnessPool) Candidates(height uint64, hash Hash) []Witness {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	out := make([]Witness, 0, len(p.byHeight[height]))
+	for _, w := range p.byHeight[height] {
+		if w.Hash == hash {
+			out = append(out, w)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return bytes.Compare(out[i].Address[:], out[j].Address[:]) < 0
+	})
+	return out
+}
+
+// Count returns the number of pending witnesses.
+func (p *WitnessPool) Count() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.size
+}
+
+// WitnessSeed derives the selection seed for the block at height from the
+// epoch seed, the hash of the block that closed the epoch before last (see
+// Blockchain.witnessDraw).
+func WitnessSeed(epochSeed Hash, height uint64) Hash {
+	h := sha256.New()
+	var buf [8]byte
+	h.Write([]byte("krypper-witness-select"))
+	h.Write(epochSeed[:])
+	binary.BigEndian.PutUint64(buf[:], height)
+	h.Write(buf[:])
+
+	var out Hash
+	copy(out[:], h.Sum(nil))
+	return out
+}
+
+// witnessScore ranks addr for seed; the lowest score is selected.
+func witnessScore(seed Hash, addr Address) Hash {
+	return Hash(sha256.Sum256(append(seed[:], addr[:]...)))
+}
+
+// SelectWitness picks the candidate with the lowest score for seed. Anyone
+// holding the candidates and seed reproduces the pick.
+func SelectWitness(candidates []Address, seed Hash) (Address, bool) {
+	if len(candidates) == 0 {
+		return Address{}, false
+	}
+	best, bestScore := candidates[0], witnessScore(seed, candidates[0])
+	for _, a := range candidates[1:] {
+		if sc := witnessScore(seed, a); bytes.Compare(sc[:], bestScore[:]) < 0 {
+			best, bestScore = a, sc
+		}
+	}
+	return best, true
+}
+
+// ChooseWitness returns what a block at height on parent carries from the
+// witnesses ws of parent: at most MaxWitnessCandidates of them to commit,
+// in address order, and the selected Tier-3 witness if ws holds its
+// signature. The state must be that of parent.
+func (bc *Blockchain) ChooseWitness(parent Hash, height uint64, ws []Witness) ([]Witness, Witness, bool) {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	// Past the limit, which witnesses are committed varies with the
+	// parent, so none is left out for long.
+	commits := append([]Witness(nil), ws...)
+	if len(commits) > MaxWitnessCandidates {
+		sort.Slice(commits, func(i, j int) bool {
+			a, b := witnessScore(parent, commits[i].Address), witnessScore(parent, commits[j].Address)
+			return bytes.Compare(a[:], b[:]) < 0
+		})
+		commits = commits[:MaxWitnessCandidates]
+	}
+	sort.Slice(commits, func(i, j int) bool {
+		return bytes.Compare(commits[i].Address[:], commits[j].Address[:]) < 0
+	})
+
+	if want, ok := bc.selectedWitness(parent, height); ok {
+		for _, w := range ws {
+			if w.Address == want {
+				return commits, w, true
+			}
+		}
+	}
+	return commits, Witness{}, false
+}
+
+// selectedWitness returns the only address that may be the Tier-3 witness
+// of the block at height on parent: the unjailed candidate of witnessDraw
+// with the lowest score. Caller must hold bc.mu and have the parent state
+// loaded.
+func (bc *Blockchain) selectedWitness(parent Hash, height uint64) (Address, bool) {
+	seed, candidates := bc.witnessDraw(parent, height)
+	eligible := make([]Address, 0, len(candidates))
+	for _, a := range candidates {
+		if !bc.state.IsJailed(a, height) {
+			eligible = append(eligible, a)
+		}
+	}
+	return SelectWitness(eligible, seed)
+}
+
+// witnessDraw returns the seed and the candidates for the Tier-3 witness
+// of the block at height on parent. The candidates are the witnesses
+// committed by the blocks of the epoch before last, except the block that
+// closed it, whose hash is the epoch seed. They are fixed on-chain before
+// the seed is known, so a proposer can neither add nor leave out one to
+// steer the pick. There are none in the first two epochs. Caller must
+// hold bc.mu.
+func (bc *Blockchain) witnessDraw(parent Hash, height uint64) (Hash, []Address) {
+	cfg := bc.executor.Config()
+	epoch := cfg.Epoch(height)
+	if epoch < 2 {
+		return Hash{}, nil
+	}
+	from := (epoch - 2) * cfg.epochLength()
+	closing := bc.ancestorAt(parent, from+cfg.epochLength()-1)
+	if closing == nil {
+		return Hash{}, nil
+	}
+
+	seen := make(map[Address]bool)
+	var candidates []Address
+	for b := bc.blockByHash(closing.Header.ParentHash); b != nil && b.Header.Height >= from; b = bc.blockByHash(b.Header.ParentHash) {
+		for _, w := range b.Header.WitnessCandidates {
+			if !seen[w.Address] {
+				seen[w.Address] = true
+				candidates = append(candidates, w.Address)
+			}
+		}
+		if b.Header.Height == 0 {
+			break
+		}
+	}
+	return WitnessSeed(closing.Hash(), height), candidates
+}
+
+// ancestorAt returns the block at height on the branch ending in h, or nil.
+// Once the walk reaches the canonical chain it reads the canonical index.
+// Caller must hold bc.mu.
+func (bc *Blockchain) ancestorAt(h Hash, height uint64) *Block {
+	b := bc.blockByHash(h)
+	for b != nil && b.Header.Height > height {
+		if bc.isCanonical(b) {
+			return bc.canonicalAt(height)
+		}
+		b = bc.blockByHash(b.Header.ParentHash)
+	}
+	if b == nil || b.Header.Height != height {
+		return nil
+	}
+	return b
+}
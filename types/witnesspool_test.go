@@ -0,0 +1,158 @@
+// SPDX-License-Identifier: MIT
+// Dev: KryperAI
+
+package types
+
+import (
+	"crypto/ecdsa"
+	"testing"
+)
+
+func mustSignWitness(t *testing.T, height uint64) Witness {
+	t.Helper()
+	k, _, _ := GenerateKey()
+	w, err := SignWitness(k, height, Hash{byte(height)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return *w
+}
+
+func TestWitnessPoolLimits(t *testing.T) {
+	p := NewWitnessPool()
+	k, _, _ := GenerateKey()
+	add := func(height, head uint64) error {
+		w, _ := SignWitness(k, height, Hash{byte(height)})
+		return p.Add(*w, head)
+	}
+
+	// One witness per height for the whole WitnessMaxAge window.
+	for h := uint64(0); h <= WitnessMaxAge; h++ {
+		if err := add(h, WitnessMaxAge); err != nil {
+			t.Fatalf("height %d: %v", h, err)
+		}
+	}
+	if err := add(WitnessMaxAge, WitnessMaxAge); err != ErrDuplicateWitness {
+		t.Fatalf("duplicate: %v", err)
+	}
+	if err := add(0, WitnessMaxAge+1); err != ErrWitnessStale {
+		t.Fatalf("expired height: %v", err)
+	}
+
+	p.Prune(WitnessMaxAge + 2)
+	if p.Count() != int(WitnessMaxAge)-1 {
+		t.Fatalf("count after prune %d", p.Count())
+	}
+}
+
+// An honest witness signs every new head. Whether the pool is pruned by a
+// producing node after each block or only as witnesses arrive, it keeps
+// just the heights inside the window.
+func TestWitnessPoolHonestWitnessNeverLimited(t *testing.T) {
+	k, _, _ := GenerateKey()
+	for _, producing := range []bool{true, false} {
+		p := NewWitnessPool()
+		for h := uint64(1); h <= 30; h++ {
+			w, _ := SignWitness(k, h, Hash{byte(h)})
+			if err := p.Add(*w, h); err != nil {
+				t.Fatalf("producing=%v height %d: %v", producing, h, err)
+			}
+			if producing {
+				p.Prune(h)
+			}
+		}
+		if p.Count() != int(WitnessMaxAge)+1 {
+			t.Fatalf("producing=%v: %d witnesses kept", producing, p.Count())
+		}
+	}
+}
+
+func TestWitnessPoolFull(t *testing.T) {
+	p := NewWitnessPool()
+	p.maxSize = 3
+	for i := 0; i < 3; i++ {
+		if err := p.Add(mustSignWitness(t, 5), 5); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := p.Add(mustSignWitness(t, 5), 5); err != ErrWitnessPoolFull {
+		t.Fatalf("full pool: %v", err)
+	}
+	// Advancing the head frees the expired entries.
+	if err := p.Add(mustSignWitness(t, 9), 9); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSelectWitness(t *testing.T) {
+	var c []Address
+	for i := 0; i < 6; i++ {
+		c = append(c, mustSignWitness(t, 4).Address)
+	}
+	seen := map[Address]bool{}
+	for i := uint64(0); i < 50; i++ {
+		seed := WitnessSeed(Hash{4}, i)
+		a, ok := SelectWitness(c, seed)
+		if !ok {
+			t.Fatal("no witness selected")
+		}
+		if again, _ := SelectWitness(c, seed); again != a {
+			t.Fatal("selection not deterministic")
+		}
+		seen[a] = true
+	}
+	if len(seen) < 4 {
+		t.Fatalf("selection not spread: %d of 6 picked", len(seen))
+	}
+	if _, ok := SelectWitness(nil, Hash{}); ok {
+		t.Fatal("selected from no candidates")
+	}
+}
+
+func TestWitnessDraw(t *testing.T) {
+	_, rich := newTestKey(t)
+	c := newTestChain(t, rich)
+	var keys []*ecdsa.PrivateKey
+	commit := func(ts int64) *Block {
+		k, _, _ := GenerateKey()
+		keys = append(keys, k)
+		b := c.sealAt(t, nil, ts, func(h *BlockHeader) {
+			w, _ := SignWitness(k, h.Height-1, h.ParentHash)
+			h.WitnessCandidates = []Witness{*w}
+		})
+		if err := c.chain.AddBlock(b); err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+	blocks := []*Block{c.chain.GetBlockByHeight(0)}
+	for ts := int64(1); ts <= 8; ts++ {
+		blocks = append(blocks, commit(ts))
+	}
+
+	// No candidates before epoch 2.
+	if _, cands := c.chain.witnessDraw(blocks[6].Hash(), 7); cands != nil {
+		t.Fatal("candidates in epoch 1")
+	}
+	// Epoch 2 draws from heights 1-2: genesis commits nothing, and the
+	// commitment of height 3 is made with the seed.
+	for _, parent := range []*Block{blocks[7], blocks[8]} {
+		h := parent.Header.Height + 1
+		seed, cands := c.chain.witnessDraw(parent.Hash(), h)
+		if seed != WitnessSeed(blocks[3].Hash(), h) {
+			t.Fatalf("height %d: seed not from height 3", h)
+		}
+		if len(cands) != 2 || !containsAddress(cands, PrivateKeyToAddress(keys[0])) || !containsAddress(cands, PrivateKeyToAddress(keys[1])) {
+			t.Fatalf("height %d: candidates %v", h, cands)
+		}
+	}
+}
+
+func containsAddress(as []Address, a Address) bool {
+	for _, x := range as {
+		if x == a {
+			return true
+		}
+	}
+	return false
+}